@@ -1,82 +1,485 @@
-package main
-
-import (
-	rl "github.com/zaklaus/raylib-go/raylib"
-	"github.com/zaklaus/rurik/src/system"
-)
-
-const (
-	screenW = 640
-	screenH = 480
-
-	// Apply 2x upscaling
-	windowW = screenW * 2
-	windowH = screenH * 2
-
-	defaultCameraZoom = 1.79
-)
-
-func init() {
-	rl.SetCallbackFunc(main)
-}
-
-func main() {
-	system.InitAssets([]string{"gfx.dta"}, true)
-
-	rl.InitWindow(800, 450, "raylib [core] example - basic window")
-
-	rl.SetTargetFPS(60)
-
-	checked := rl.GenImageChecked(32, 32, 1, 1, rl.Black, rl.White)
-	tex := rl.LoadTextureFromImage(checked)
-	rl.UnloadImage(checked)
-
-	cam := rl.NewCamera3D(
-		rl.NewVector3(5, -5, 5),
-		rl.NewVector3(0, 0, 0),
-		rl.NewVector3(0, 1, 0),
-		62,
-		0,
-	)
-
-	modelsnap := system.CreateRenderTarget(screenW, screenH)
-
-	rl.BeginTextureMode(modelsnap)
-	{
-		rl.BeginMode3D(cam)
-		{
-			rl.ClearBackground(rl.Blank)
-			rl.DrawCubeTexture(tex, rl.Vector3{}, 4, 4, 4, rl.White)
-			rl.DrawGrid(10, 1)
-		}
-		rl.EndMode3D()
-	}
-	rl.EndTextureMode()
-
-	avatar := system.GetTexture("gfx/avatar.png")
-
-	for !rl.WindowShouldClose() {
-		rl.BeginDrawing()
-
-		rl.ClearBackground(rl.RayWhite)
-
-		rl.DrawTexturePro(modelsnap.Texture,
-			rl.NewRectangle(0, 0, screenW, screenH),
-			rl.NewRectangle(0, 0, screenW, screenH), rl.Vector2{}, 0,
-			rl.White)
-		rl.DrawText("Congrats! You created your first window!", 190, 200, 20, rl.LightGray)
-		rl.DrawTexturePro(
-			*avatar,
-			rl.NewRectangle(0, 0, float32(avatar.Width), float32(avatar.Height)),
-			rl.NewRectangle(0, 0, 200, 200),
-			rl.Vector2{},
-			0,
-			rl.White,
-		)
-
-		rl.EndDrawing()
-		rl.PollInputEvents()
-	}
-
-	rl.CloseWindow()
-}
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/zaklaus/rurik/src/system"
+)
+
+const usage = `rurik-archive manages the game data archives
+
+Usage:
+	rurik-archive build [-tags dir] [-assets dir] [-out dir] [-sign keyfile] [name ...]
+	rurik-archive list archive.dta
+	rurik-archive extract [-out dir] archive.dta [file ...]
+	rurik-archive diff old.dta new.dta
+	rurik-archive verify [-trust key] [-signed] archive.dta ...
+	rurik-archive keygen name
+
+Every command accepts -key and -keyfile to specify the encryption key,
+an empty key builds unencrypted archives. Keys can also be supplied using
+the RURIK_ARCHIVE_KEY, RURIK_ARCHIVE_KEY_FILE, RURIK_ARCHIVE_SIGNING_KEY_FILE
+and RURIK_ARCHIVE_TRUSTED_KEYS environment variables. Setting RURIK_REQUIRE_SIGNED
+to true works like -signed, the game then refuses unsigned base archives
+and skips unsigned patches and mods.
+`
+
+var commands = map[string]func(args []string) error{
+	"build":   buildCommand,
+	"list":    listCommand,
+	"extract": extractCommand,
+	"diff":    diffCommand,
+	"verify":  verifyCommand,
+	"keygen":  keygenCommand,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command '%s'\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	err := system.InitArchiveKeys()
+
+	if err == nil {
+		err = cmd(os.Args[2:])
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rurik-archive %s: %s\n", os.Args[1], err.Error())
+		os.Exit(1)
+	}
+}
+
+// keyFlags registers the encryption key flags, the returned function applies them
+func keyFlags(fs *flag.FlagSet) func() error {
+	key := fs.String("key", system.ArchiveEncryptionKey, "Archive encryption key, empty disables encryption.")
+	keyFile := fs.String("keyfile", "", "File containing the archive encryption key.")
+
+	return func() error {
+		system.ArchiveEncryptionKey = *key
+
+		if *keyFile != "" {
+			k, err := system.ReadKeyFile(*keyFile)
+
+			if err != nil {
+				return err
+			}
+
+			system.ArchiveEncryptionKey = k
+		}
+
+		return nil
+	}
+}
+
+func buildCommand(args []string) error {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	applyKeys := keyFlags(fs)
+	signKeyFile := fs.String("sign", "", "File containing the ed25519 private key used to sign archives.")
+	tagsDir := fs.String("tags", "tags", "Directory containing .rtag files.")
+	assetsDir := fs.String("assets", "assets", "Directory the asset paths are relative to.")
+	outDir := fs.String("out", "data", "Output directory for built archives.")
+	fs.Parse(args)
+
+	if err := applyKeys(); err != nil {
+		return err
+	}
+
+	if *signKeyFile != "" {
+		key, err := system.LoadSigningKey(*signKeyFile)
+
+		if err != nil {
+			return err
+		}
+
+		system.ArchiveSigningKey = key
+	}
+
+	names := fs.Args()
+
+	if len(names) == 0 {
+		files, err := filepath.Glob(filepath.Join(*tagsDir, "*.rtag"))
+
+		if err != nil {
+			return err
+		}
+
+		names = files
+	} else {
+		for i, v := range names {
+			if path.Ext(v) != ".rtag" {
+				names[i] = filepath.Join(*tagsDir, strings.TrimSuffix(v, ".dta")+".rtag")
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return fmt.Errorf("no .rtag files found in '%s'", *tagsDir)
+	}
+
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		return err
+	}
+
+	for _, v := range names {
+		a, err := system.BuildArchive(v, *assetsDir)
+
+		if err != nil {
+			return err
+		}
+
+		outName := filepath.Join(*outDir, system.ArchiveNameFromTagFile(v))
+		err = system.WriteArchiveFile(outName, a)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s: %d chunks -> %s\n", v, len(a.Chunks), outName)
+	}
+
+	return nil
+}
+
+func listCommand(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	applyKeys := keyFlags(fs)
+	fs.Parse(args)
+
+	if err := applyKeys(); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected exactly one archive")
+	}
+
+	a, err := system.LoadArchiveFile(fs.Arg(0))
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s by %s\n%s\n%s\n\n", a.Name, a.Version, a.Author, a.Description, describeSeal(&a))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tTYPE\tTAGS\tSIZE\tAUTHOR")
+
+	var totalSize int
+
+	for _, v := range a.Chunks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", v.FileName, v.TypeName(), formatTags(v), len(v.Data), v.Author)
+		totalSize += len(v.Data)
+	}
+
+	w.Flush()
+	fmt.Printf("\n%d chunks, %d bytes\n", len(a.Chunks), totalSize)
+
+	return nil
+}
+
+func extractCommand(args []string) error {
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+	applyKeys := keyFlags(fs)
+	outDir := fs.String("out", "assets", "Output directory for extracted files.")
+	fs.Parse(args)
+
+	if err := applyKeys(); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("expected an archive")
+	}
+
+	a, err := system.LoadArchiveFile(fs.Arg(0))
+
+	if err != nil {
+		return err
+	}
+
+	chunks := a.Chunks
+
+	if fs.NArg() > 1 {
+		chunks = []system.AssetChunk{}
+
+		for _, v := range fs.Args()[1:] {
+			ch := a.FindChunk(v)
+
+			if ch == nil {
+				return fmt.Errorf("file '%s' was not found in %s", v, fs.Arg(0))
+			}
+
+			chunks = append(chunks, *ch)
+		}
+	}
+
+	for _, v := range chunks {
+		fileName, err := getExtractPath(*outDir, v.FileName)
+
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(fileName, v.Data, 0644); err != nil {
+			return err
+		}
+
+		fmt.Println(fileName)
+	}
+
+	return nil
+}
+
+// getExtractPath returns where the archived file is written to, names escaping the output directory are rejected
+func getExtractPath(outDir, name string) (string, error) {
+	name = filepath.FromSlash(name)
+
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("file '%s' has an absolute path", name)
+	}
+
+	target := filepath.Join(outDir, name)
+	rel, err := filepath.Rel(filepath.Clean(outDir), target)
+
+	if err != nil {
+		return "", err
+	}
+
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("file '%s' would be extracted outside of %s", name, outDir)
+	}
+
+	return target, nil
+}
+
+func diffCommand(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	applyKeys := keyFlags(fs)
+	fs.Parse(args)
+
+	if err := applyKeys(); err != nil {
+		return err
+	}
+
+	args = fs.Args()
+
+	if len(args) != 2 {
+		return fmt.Errorf("expected two archives")
+	}
+
+	oldArchive, err := system.LoadArchiveFile(args[0])
+
+	if err != nil {
+		return err
+	}
+
+	newArchive, err := system.LoadArchiveFile(args[1])
+
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+
+	for _, v := range oldArchive.Chunks {
+		names = append(names, v.FileName)
+	}
+
+	for _, v := range newArchive.Chunks {
+		if oldArchive.FindChunk(v.FileName) == nil {
+			names = append(names, v.FileName)
+		}
+	}
+
+	sort.Strings(names)
+
+	changes := 0
+
+	for _, v := range names {
+		a := oldArchive.FindChunk(v)
+		b := newArchive.FindChunk(v)
+
+		switch {
+		case a == nil:
+			fmt.Printf("+ %s (%d bytes)\n", v, len(b.Data))
+		case b == nil:
+			fmt.Printf("- %s (%d bytes)\n", v, len(a.Data))
+		case !bytes.Equal(a.Data, b.Data):
+			fmt.Printf("M %s (%d -> %d bytes)\n", v, len(a.Data), len(b.Data))
+		case a.Type != b.Type || a.Author != b.Author || formatTags(*a) != formatTags(*b):
+			fmt.Printf("m %s (metadata)\n", v)
+		default:
+			continue
+		}
+
+		changes++
+	}
+
+	fmt.Printf("\n%d changed files\n", changes)
+
+	return nil
+}
+
+func verifyCommand(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	applyKeys := keyFlags(fs)
+	trust := fs.String("trust", "", "Comma-separated list of trusted public keys or .pub files.")
+	fs.BoolVar(&system.RequireSignedArchives, "signed", system.RequireSignedArchives, "Require archives to be signed by a trusted key.")
+	fs.Parse(args)
+
+	if err := applyKeys(); err != nil {
+		return err
+	}
+
+	for _, v := range strings.Split(*trust, ",") {
+		if v == "" {
+			continue
+		}
+
+		if _, err := os.Stat(v); err == nil {
+			key, err := system.ReadKeyFile(v)
+
+			if err != nil {
+				return err
+			}
+
+			v = key
+		}
+
+		if err := system.TrustArchiveKey(v); err != nil {
+			return err
+		}
+	}
+
+	args = fs.Args()
+
+	if len(args) < 1 {
+		return fmt.Errorf("expected at least one archive")
+	}
+
+	failed := 0
+
+	for _, v := range args {
+		a, err := system.LoadArchiveFile(v)
+
+		if err != nil {
+			fmt.Printf("%s: FAIL\n\t%s\n", v, err.Error())
+			failed++
+			continue
+		}
+
+		errs := a.Verify()
+
+		if len(errs) > 0 {
+			fmt.Printf("%s: FAIL\n", v)
+
+			for _, e := range errs {
+				fmt.Printf("\t%s\n", e.Error())
+			}
+
+			failed++
+			continue
+		}
+
+		fmt.Printf("%s: OK (%d chunks, %s)\n", v, len(a.Chunks), describeSeal(&a))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d archives failed verification", failed, len(args))
+	}
+
+	return nil
+}
+
+func keygenCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected a key name")
+	}
+
+	pub, priv, err := system.GenerateSigningKey()
+
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(args[0]+".key", []byte(priv+"\n"), 0600); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(args[0]+".pub", []byte(pub+"\n"), 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s.key: private key, keep it secret\n%s.pub: %s\n", args[0], args[0], pub)
+
+	return nil
+}
+
+func describeSeal(a *system.AssetArchive) string {
+	desc := "unencrypted"
+
+	if a.IsEncrypted() {
+		desc = "encrypted"
+	}
+
+	switch {
+	case a.Signer() == nil:
+		desc += ", unsigned"
+	case a.IsTrusted():
+		desc += ", signed by trusted key " + hex.EncodeToString(a.Signer())
+	default:
+		desc += ", signed by unknown key " + hex.EncodeToString(a.Signer())
+	}
+
+	return desc
+}
+
+func formatTags(ch system.AssetChunk) string {
+	tags := []string{}
+
+	for i, v := range ch.TagNames() {
+		tags = append(tags, fmt.Sprintf("%s=%g", v, ch.TagValues[i]))
+	}
+
+	axes := []string{}
+
+	for k, v := range ch.Axes {
+		axes = append(axes, fmt.Sprintf("%s:%s", k, v))
+	}
+
+	sort.Strings(axes)
+	tags = append(tags, axes...)
+
+	if ch.Variant != "" {
+		tags = append(tags, "variantof:"+ch.Variant)
+	}
+
+	if len(tags) == 0 {
+		return "-"
+	}
+
+	return strings.Join(tags, ",")
+}
@@ -0,0 +1,208 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"math"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+)
+
+var (
+	// SpriteBatchingEnabled toggles grouping of the sprite draws by their texture and blend mode
+	SpriteBatchingEnabled = true
+
+	// SpriteBatchLookback is the number of batches a sprite may skip to join a batch sharing its texture
+	SpriteBatchLookback = 8
+
+	// SpritesDrawn and SpriteBatchesDrawn are the statistics of the last frame
+	SpritesDrawn       int
+	SpriteBatchesDrawn int
+
+	spritesDrawn       int
+	spriteBatchesDrawn int
+
+	spriteBatch SpriteBatch
+)
+
+// SpriteBatch collects the sprite draws and reorders them into as few batches as possible,
+// a sprite is only moved before the sprites it does not overlap, so the result looks the same
+type SpriteBatch struct {
+	groups []*spriteGroup
+	count  int
+	isOpen bool
+}
+
+type spriteGroup struct {
+	texture uint32
+	blend   rl.BlendMode
+	bounds  rl.Rectangle
+	draws   []spriteDraw
+}
+
+type spriteDraw struct {
+	texture  rl.Texture2D
+	source   rl.Rectangle
+	dest     rl.Rectangle
+	origin   rl.Vector2
+	rotation float32
+	tint     rl.Color
+	bounds   rl.Rectangle
+}
+
+// DrawSprite draws the texture's region, sprites drawn by the objects and particles get batched
+func DrawSprite(tex rl.Texture2D, source, dest rl.Rectangle, origin rl.Vector2, rotation float32, tint rl.Color) {
+	DrawSpriteBlended(tex, source, dest, origin, rotation, tint, rl.BlendAlpha)
+}
+
+// DrawSpriteBlended draws the texture's region using the blend mode
+func DrawSpriteBlended(tex rl.Texture2D, source, dest rl.Rectangle, origin rl.Vector2, rotation float32, tint rl.Color, blend rl.BlendMode) {
+	d := spriteDraw{
+		texture:  tex,
+		source:   source,
+		dest:     dest,
+		origin:   origin,
+		rotation: rotation,
+		tint:     tint,
+	}
+
+	if !spriteBatch.isOpen {
+		spritesDrawn++
+		spriteBatchesDrawn++
+		drawSprites([]spriteDraw{d}, blend)
+		return
+	}
+
+	d.bounds = getSpriteBounds(dest, origin, rotation)
+	spriteBatch.add(d, blend)
+}
+
+// FlushSpriteBatch draws all queued sprites,
+// it has to be called before drawing anything else in between the batched sprites
+func FlushSpriteBatch() {
+	b := &spriteBatch
+
+	for _, g := range b.groups[:b.count] {
+		drawSprites(g.draws, g.blend)
+		spritesDrawn += len(g.draws)
+	}
+
+	spriteBatchesDrawn += b.count
+	b.count = 0
+}
+
+// beginSpriteBatch starts queueing the sprite draws
+func beginSpriteBatch() {
+	spriteBatch.isOpen = true
+}
+
+// endSpriteBatch draws the queued sprites and stops queueing
+func endSpriteBatch() {
+	FlushSpriteBatch()
+	spriteBatch.isOpen = false
+}
+
+// resetSpriteBatchStats publishes the statistics of the frame
+func resetSpriteBatchStats() {
+	SpritesDrawn, SpriteBatchesDrawn = spritesDrawn, spriteBatchesDrawn
+	spritesDrawn, spriteBatchesDrawn = 0, 0
+}
+
+// add puts the sprite into the latest batch sharing its texture unless a sprite in between overlaps it,
+// without batching the sprites are only grouped with the previous one
+func (b *SpriteBatch) add(d spriteDraw, blend rl.BlendMode) {
+	lookback := 1
+
+	if SpriteBatchingEnabled {
+		lookback = SpriteBatchLookback
+	}
+
+	for i := b.count - 1; i >= 0 && i >= b.count-lookback; i-- {
+		g := b.groups[i]
+
+		if g.texture == d.texture.ID && g.blend == blend {
+			g.draws = append(g.draws, d)
+			g.bounds = unionRectangles(g.bounds, d.bounds)
+			return
+		}
+
+		if g.overlaps(d.bounds) {
+			break
+		}
+	}
+
+	if b.count == len(b.groups) {
+		b.groups = append(b.groups, &spriteGroup{})
+	}
+
+	g := b.groups[b.count]
+	g.texture = d.texture.ID
+	g.blend = blend
+	g.bounds = d.bounds
+	g.draws = append(g.draws[:0], d)
+	b.count++
+}
+
+func (g *spriteGroup) overlaps(r rl.Rectangle) bool {
+	if !rl.CheckCollisionRecs(g.bounds, r) {
+		return false
+	}
+
+	for _, d := range g.draws {
+		if rl.CheckCollisionRecs(d.bounds, r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func drawSprites(draws []spriteDraw, blend rl.BlendMode) {
+	if blend != rl.BlendAlpha {
+		rl.BeginBlendMode(blend)
+	}
+
+	for _, d := range draws {
+		rl.DrawTexturePro(d.texture, d.source, d.dest, d.origin, d.rotation, d.tint)
+	}
+
+	if blend != rl.BlendAlpha {
+		rl.EndBlendMode()
+	}
+}
+
+// getSpriteBounds returns the area covered by the sprite, rotated sprites use the circle around their pivot
+func getSpriteBounds(dest rl.Rectangle, origin rl.Vector2, rotation float32) rl.Rectangle {
+	if rotation == 0 {
+		return rl.NewRectangle(dest.X-origin.X, dest.Y-origin.Y, dest.Width, dest.Height)
+	}
+
+	w := math.Max(float64(origin.X), float64(dest.Width-origin.X))
+	h := math.Max(float64(origin.Y), float64(dest.Height-origin.Y))
+	r := float32(math.Sqrt(w*w + h*h))
+
+	return rl.NewRectangle(dest.X-r, dest.Y-r, r*2, r*2)
+}
+
+func unionRectangles(a, b rl.Rectangle) rl.Rectangle {
+	x := float32(math.Min(float64(a.X), float64(b.X)))
+	y := float32(math.Min(float64(a.Y), float64(b.Y)))
+	w := float32(math.Max(float64(a.X+a.Width), float64(b.X+b.Width))) - x
+	h := float32(math.Max(float64(a.Y+a.Height), float64(b.Y+b.Height))) - y
+
+	return rl.NewRectangle(x, y, w, h)
+}
@@ -0,0 +1,249 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/Knetic/govaluate"
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/raylib-go/raymath"
+	"github.com/zaklaus/rurik/src/system"
+	"gopkg.in/yaml.v2"
+)
+
+// Brain is a state machine driving an NPC, loaded from "ai/<name>.yaml"
+type Brain struct {
+	Name    string
+	Initial string                 `yaml:"initial"`
+	States  map[string]*BrainState `yaml:"states"`
+}
+
+// BrainState describes the behaviour of a state and the conditions leaving it
+type BrainState struct {
+	Behaviour   string             `yaml:"behaviour"`
+	Target      string             `yaml:"target"`
+	Path        string             `yaml:"path"`
+	Speed       float32            `yaml:"speed"`
+	Radius      float32            `yaml:"radius"`
+	Enter       string             `yaml:"enter"`
+	Exit        string             `yaml:"exit"`
+	Transitions []*BrainTransition `yaml:"transitions"`
+}
+
+// BrainTransition switches the state once its condition evaluates to true
+type BrainTransition struct {
+	When string `yaml:"when"`
+	To   string `yaml:"to"`
+
+	expr *govaluate.EvaluableExpression
+}
+
+// BehaviourFunc returns the velocity of an NPC in the given state
+type BehaviourFunc func(o *Object, s *BrainState, dt float32) rl.Vector2
+
+var (
+	brains                 = make(map[string]*Brain)
+	behaviours             = make(map[string]BehaviourFunc)
+	brainFunctions         = make(map[string]govaluate.ExpressionFunction)
+	brainSubject           *Object
+	areBrainFunctionsReady bool
+)
+
+// RegisterBehaviour adds a custom behaviour usable by brain states
+func RegisterBehaviour(name string, fn BehaviourFunc) {
+	behaviours[name] = fn
+}
+
+// RegisterBrainFunction adds a custom function usable by transition conditions,
+// GetBrainSubject returns the NPC the condition is evaluated for
+func RegisterBrainFunction(name string, fn govaluate.ExpressionFunction) {
+	brainFunctions[name] = fn
+}
+
+// GetBrainSubject returns the NPC whose brain is being evaluated
+func GetBrainSubject() *Object {
+	return brainSubject
+}
+
+// GetBrain loads the brain or returns the cached one
+func GetBrain(name string) *Brain {
+	if b, ok := brains[name]; ok {
+		return b
+	}
+
+	asset := system.FindAsset(fmt.Sprintf("ai/%s.yaml", name))
+
+	if asset == nil {
+		log.Printf("Brain '%s' could not be found!\n", name)
+		return nil
+	}
+
+	b := &Brain{Name: name}
+
+	if err := yaml.Unmarshal(asset.Data, b); err != nil {
+		log.Printf("Brain '%s' could not be loaded: %s\n", name, err.Error())
+		return nil
+	}
+
+	if _, ok := b.States[b.Initial]; !ok {
+		log.Printf("Brain '%s' has no initial state '%s'!\n", name, b.Initial)
+		return nil
+	}
+
+	for stateName, s := range b.States {
+		for _, t := range s.Transitions {
+			if _, ok := b.States[t.To]; !ok {
+				log.Printf("Brain '%s' state '%s' has a transition to unknown state '%s'!\n", name, stateName, t.To)
+			}
+		}
+	}
+
+	brains[name] = b
+	return b
+}
+
+// FlushBrains drops the cached brains, so they get reloaded on the next use
+func FlushBrains() {
+	brains = make(map[string]*Brain)
+}
+
+// evaluate checks the transition's condition for the NPC
+func (t *BrainTransition) evaluate(o *Object) bool {
+	initBrainFunctions()
+
+	if t.expr == nil {
+		expr, err := govaluate.NewEvaluableExpressionWithFunctions(t.When, brainFunctions)
+
+		if err != nil {
+			log.Printf("Brain condition '%s' is invalid: %s\n", t.When, err.Error())
+			t.When = "false"
+			t.expr, _ = govaluate.NewEvaluableExpression("false")
+			return false
+		}
+
+		t.expr = expr
+	}
+
+	brainSubject = o
+	res, err := t.expr.Evaluate(map[string]interface{}{
+		"time": float64(o.StateTime),
+	})
+	brainSubject = nil
+
+	if err != nil {
+		log.Printf("Brain condition '%s' failed: %s\n", t.When, err.Error())
+		return false
+	}
+
+	ok, _ := res.(bool)
+	return ok
+}
+
+func initBrainFunctions() {
+	if areBrainFunctionsReady {
+		return
+	}
+
+	areBrainFunctionsReady = true
+
+	target := func(args []interface{}) *Object {
+		name := ""
+
+		if len(args) > 0 {
+			name = fmt.Sprintf("%v", args[0])
+		}
+
+		return brainSubject.getNPCTarget(name)
+	}
+
+	defaults := map[string]govaluate.ExpressionFunction{
+		"sees": func(args ...interface{}) (interface{}, error) {
+			return brainSubject.Perception.CanSee(brainSubject, target(args)), nil
+		},
+		"hears": func(args ...interface{}) (interface{}, error) {
+			return brainSubject.Perception.CanHear(brainSubject, target(args)), nil
+		},
+		"distance": func(args ...interface{}) (interface{}, error) {
+			t := target(args)
+
+			if t == nil {
+				return float64(-1), nil
+			}
+
+			return float64(raymath.Vector2Distance(t.Position, brainSubject.Position)), nil
+		},
+		"arrived": func(args ...interface{}) (interface{}, error) {
+			return brainSubject.Movement.X == 0 && brainSubject.Movement.Y == 0, nil
+		},
+	}
+
+	for k, v := range defaults {
+		if _, ok := brainFunctions[k]; !ok {
+			brainFunctions[k] = v
+		}
+	}
+}
+
+func initDefaultBehaviours() {
+	RegisterBehaviour("idle", func(o *Object, s *BrainState, dt float32) rl.Vector2 {
+		return rl.Vector2{}
+	})
+
+	RegisterBehaviour("seek", func(o *Object, s *BrainState, dt float32) rl.Vector2 {
+		return o.steerTowards(o.getNPCTarget(s.Target), o.getNPCSpeed(s), 0, dt)
+	})
+
+	RegisterBehaviour("arrive", func(o *Object, s *BrainState, dt float32) rl.Vector2 {
+		return o.steerTowards(o.getNPCTarget(s.Target), o.getNPCSpeed(s), s.Radius, dt)
+	})
+
+	RegisterBehaviour("flee", func(o *Object, s *BrainState, dt float32) rl.Vector2 {
+		t := o.getNPCTarget(s.Target)
+
+		if t == nil {
+			return rl.Vector2{}
+		}
+
+		return SteerFlee(o, t.Position, o.getNPCSpeed(s))
+	})
+
+	RegisterBehaviour("wander", func(o *Object, s *BrainState, dt float32) rl.Vector2 {
+		return SteerWander(&o.WanderHeading, o.getNPCSpeed(s), dt)
+	})
+
+	RegisterBehaviour("patrol", func(o *Object, s *BrainState, dt float32) rl.Vector2 {
+		return SteerPatrol(o, o.getPatrolPoints(s.Path), &o.PatrolIndex, o.getNPCSpeed(s))
+	})
+
+	RegisterBehaviour("follow", func(o *Object, s *BrainState, dt float32) rl.Vector2 {
+		t := o.getNPCTarget(s.Target)
+
+		if t == nil {
+			return rl.Vector2{}
+		}
+
+		distance := s.Radius
+
+		if distance == 0 {
+			distance = 24
+		}
+
+		return SteerFollow(o, t, o.getNPCSpeed(s), distance)
+	})
+}
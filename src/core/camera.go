@@ -1,273 +1,719 @@
-/*
-   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
-
-   Licensed under the Apache License, Version 2.0 (the "License");
-   you may not use this file except in compliance with the License.
-   You may obtain a copy of the License at
-
-       http://www.apache.org/licenses/LICENSE-2.0
-
-   Unless required by applicable law or agreed to in writing, software
-   distributed under the License is distributed on an "AS IS" BASIS,
-   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
-   See the License for the specific language governing permissions and
-   limitations under the License.
-*/
-
-package core
-
-import (
-	"encoding/gob"
-	"fmt"
-	"log"
-	"math"
-	"strconv"
-
-	rl "github.com/zaklaus/raylib-go/raylib"
-	"github.com/zaklaus/raylib-go/raymath"
-)
-
-const (
-	// CameraModeStatic stationary camera
-	CameraModeStatic = 1
-
-	// CameraModeFollow camera following an object
-	CameraModeFollow = 2
-
-	// CameraModeLerp camera transiting between two objects
-	CameraModeLerp = 3
-)
-
-type camera struct {
-	Follow     *Object
-	Start      *Object
-	End        *Object
-	Speed      float32
-	Progress   float32
-	Zoom       float32
-	TargetZoom float32
-	ZoomSpeed  float32
-	Smoothing  float32
-	Mode       int
-	First      bool
-	FollowName string
-}
-
-type cameraData struct {
-	Follow     string
-	Start      string
-	End        string
-	Speed      float32
-	Progress   float32
-	TargetZoom float32
-	ZoomSpeed  float32
-	Smoothing  float32
-	Mode       int
-	First      bool
-}
-
-// NewCamera game camera
-func (c *Object) NewCamera() {
-	c.Update = updateCamera
-	c.Finish = finishCamera
-	c.Zoom = 1
-	c.TargetZoom = c.Zoom
-	c.ZoomSpeed = 0.8
-	c.Smoothing = 0.8
-	c.DebugVisible = false
-	c.First = true
-	followName := c.Meta.Properties.GetString("follow")
-	if followName == "" {
-		c.FollowName = "player"
-	} else {
-		c.FollowName = followName
-	}
-	c.Mode = CameraModeFollow
-	strMode := c.Meta.Properties.GetString("mode")
-	if strMode != "" {
-		c.SetCameraMode(strMode)
-	}
-	spd, _ := strconv.ParseFloat(c.Meta.Properties.GetString("speed"), 32)
-
-	zoom, _ := strconv.ParseFloat(c.Meta.Properties.GetString("zoom"), 1)
-
-	if zoom != 0 {
-		c.SetCameraZoom(float32(zoom))
-	}
-
-	if spd == 0 {
-		spd = 1
-	}
-
-	if MainCamera == nil {
-		MainCamera = c
-	}
-
-	c.Serialize = func(o *Object, enc *gob.Encoder) {
-		enc.Encode(&cameraData{
-			Follow:     o.FollowName,
-			Start:      "",
-			End:        "",
-			Speed:      o.Speed,
-			Progress:   o.Progress,
-			TargetZoom: o.TargetZoom,
-			ZoomSpeed:  o.ZoomSpeed,
-			Smoothing:  o.Smoothing,
-			Mode:       o.Mode,
-			First:      o.First,
-		})
-	}
-
-	c.Deserialize = func(o *Object, dec *gob.Decoder) {
-		var dat cameraData
-		dec.Decode(&dat)
-
-		/* o.Follow = dat.Follow
-		o.Start = dat.Start
-		o.End = dat.End */
-		o.Speed = dat.Speed
-		o.Progress = dat.Progress
-		o.TargetZoom = dat.TargetZoom
-		o.ZoomSpeed = dat.ZoomSpeed
-		o.Smoothing = dat.Smoothing
-		o.Mode = dat.Mode
-		o.First = dat.First
-		o.FollowName = dat.Follow
-	}
-
-	c.Speed = float32(spd)
-
-	c.Draw = func(o *Object) {
-		if !DebugMode || !o.DebugVisible {
-			return
-		}
-
-		mode := "static"
-
-		switch o.Mode {
-		case 2:
-			mode = "follow"
-		case 3:
-			mode = "lerp"
-		}
-
-		rl.DrawCircle(int32(o.Position.X), int32(o.Position.Y), 2, rl.White)
-		DrawTextCentered(
-			fmt.Sprintf("%s", o.Name),
-			int32(o.Position.X),
-			int32(o.Position.Y)+5,
-			10,
-			rl.White,
-		)
-
-		DrawTextCentered(
-			fmt.Sprintf("Mode: %s", mode),
-			int32(o.Position.X),
-			int32(o.Position.Y)+15,
-			10,
-			rl.White,
-		)
-	}
-}
-
-func finishCamera(c *Object) {
-	if c.Mode == CameraModeFollow {
-		c.Follow, _ = c.world.FindObject(c.FollowName)
-
-		if c.First && c.Follow != nil {
-			c.Position = c.Follow.Position
-		}
-	} else if c.Mode == CameraModeLerp {
-		c.Start, _ = c.world.FindObject(c.Meta.Properties.GetString("start"))
-		c.End, _ = c.world.FindObject(c.Meta.Properties.GetString("end"))
-	}
-}
-
-func updateCamera(c *Object, dt float32) {
-	var dest rl.Vector2
-
-	CanSave = BitsSet(CanSave, IsSequenceHappening)
-
-	if c.Mode == CameraModeFollow {
-		if c.Follow == nil {
-			log.Println("Camera object follows nil reference.")
-			return
-		}
-
-		if c.Follow == LocalPlayer {
-			CanSave = BitsClear(CanSave, IsSequenceHappening)
-		}
-
-		dest = Vector2Lerp(c.Position, c.Follow.Position, c.Smoothing)
-	} else if c.Mode == CameraModeLerp {
-		if c.Start == nil || c.End == nil {
-			log.Println("Camera object lerps between nil references.")
-			return
-		}
-
-		if c.First {
-			c.Position = c.Start.Position
-		}
-
-		dest = c.End.Position
-	} else {
-		dest = c.Position
-	}
-
-	dest.X = float32(math.Round(float64(dest.X)))
-	dest.Y = float32(math.Round(float64(dest.Y)))
-
-	if !c.First || c.Mode == CameraModeLerp {
-		t := c.Speed
-
-		if c.Mode == CameraModeLerp {
-			vd := raymath.Vector2Distance(dest, c.Position)
-
-			if c.Progress > 1 {
-				c.Progress = 1
-
-				c.Mode = CameraModeStatic
-
-				if c.End.EventName != "" {
-					FireEvent(c.End.EventName, c.End.EventArgs)
-				}
-			}
-
-			if t > vd {
-				t = vd
-			}
-
-			c.Position = Vector2Lerp(c.Start.Position, dest, c.Progress)
-			c.Progress += t * dt
-		} else {
-			c.Position = Vector2Lerp(c.Position, dest, t)
-		}
-	} else {
-		c.Position = dest
-	}
-
-	c.Zoom = ScalarLerp(c.Zoom, c.TargetZoom, c.ZoomSpeed*dt)
-	RenderCamera.Zoom = c.Zoom
-
-	c.First = false
-}
-
-// SetCameraZoom overrides camera zoom
-func (c *Object) SetCameraZoom(t float32) {
-	c.Zoom = t
-	c.TargetZoom = t
-}
-
-// SetCameraMode sets the camera behavior mode
-func (c *Object) SetCameraMode(strMode string) {
-	switch strMode {
-	default:
-		fallthrough
-	case "static":
-		c.Mode = 1
-	case "follow":
-		c.Mode = 2
-	case "lerp":
-		c.Mode = 3
-	}
-}
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"encoding/gob"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/raylib-go/raymath"
+	"github.com/zaklaus/rurik/src/system"
+)
+
+const (
+	// CameraModeStatic stationary camera
+	CameraModeStatic = 1
+
+	// CameraModeFollow camera following an object
+	CameraModeFollow = 2
+
+	// CameraModeLerp camera transiting between two objects
+	CameraModeLerp = 3
+
+	// CameraModeRail camera travelling along a spline
+	CameraModeRail = 4
+
+	// CameraModeFrame camera keeping multiple objects on screen
+	CameraModeFrame = 5
+)
+
+var (
+	// CameraShakeDecay is the amount of trauma removed per second
+	CameraShakeDecay float32 = 1.5
+
+	// CameraShakeMaxOffset is the offset in pixels of a camera with full trauma
+	CameraShakeMaxOffset float32 = 8
+
+	// CameraShakeMaxAngle is the rotation in degrees of a camera with full trauma
+	CameraShakeMaxAngle float32 = 3
+
+	// CameraShakeFrequency is the speed of the shake noise
+	CameraShakeFrequency float32 = 20
+
+	// CameraLookAheadSpeed is the rate at which the look-ahead offset follows the target's facing
+	CameraLookAheadSpeed float32 = 3
+)
+
+type camera struct {
+	Follow     *Object
+	Start      *Object
+	End        *Object
+	Speed      float32
+	Progress   float32
+	Zoom       float32
+	TargetZoom float32
+	ZoomSpeed  float32
+	Smoothing  float32
+	Mode       int
+	First      bool
+	FollowName string
+
+	// Follow
+	DeadZone   rl.Vector2
+	LookAhead  float32
+	lookOffset rl.Vector2
+
+	// Bounds
+	Bounds     rl.Rectangle
+	HasBounds  bool
+	BoundsName string
+
+	// Rail
+	RailName     string
+	RailDuration float32
+	RailEasing   string
+	rail         *Spline
+
+	// Frame
+	Targets      []*Object
+	TargetNames  []string
+	FramePadding float32
+	MinZoom      float32
+	MaxZoom      float32
+
+	// Shake
+	Trauma      float32
+	ShakeOffset rl.Vector2
+	ShakeAngle  float32
+	shakeTime   float32
+}
+
+type cameraData struct {
+	Follow     string
+	Start      string
+	End        string
+	Speed      float32
+	Progress   float32
+	TargetZoom float32
+	ZoomSpeed  float32
+	Smoothing  float32
+	Mode       int
+	First      bool
+
+	DeadZone     rl.Vector2
+	LookAhead    float32
+	Bounds       rl.Rectangle
+	HasBounds    bool
+	BoundsName   string
+	RailName     string
+	RailDuration float32
+	RailEasing   string
+	TargetNames  []string
+	FramePadding float32
+	MinZoom      float32
+	MaxZoom      float32
+}
+
+// newCameraComponent game camera
+func newCameraComponent(c *Object, comp *Component) {
+	comp.Update = updateCamera
+	comp.Finish = finishCamera
+	c.Zoom = 1
+	c.TargetZoom = c.Zoom
+	c.ZoomSpeed = 0.8
+	c.Smoothing = 0.8
+	c.DebugVisible = false
+	c.First = true
+	followName := c.Meta.Properties.GetString("follow")
+	if followName == "" {
+		c.FollowName = "player"
+	} else {
+		c.FollowName = followName
+	}
+	c.Mode = CameraModeFollow
+	strMode := c.Meta.Properties.GetString("mode")
+	if strMode != "" {
+		c.SetCameraMode(strMode)
+	}
+	spd, _ := strconv.ParseFloat(c.Meta.Properties.GetString("speed"), 32)
+
+	zoom, _ := strconv.ParseFloat(c.Meta.Properties.GetString("zoom"), 1)
+
+	if zoom != 0 {
+		c.SetCameraZoom(float32(zoom))
+	}
+
+	if spd == 0 {
+		spd = 1
+	}
+
+	c.DeadZone = GetVec2FromProperty(c.Meta, "deadzone")
+	c.LookAhead = GetFloatFromProperty(c.Meta, "lookahead")
+	c.BoundsName = c.Meta.Properties.GetString("bounds")
+	c.RailName = c.Meta.Properties.GetString("rail")
+	c.RailDuration = GetFloatFromProperty(c.Meta, "duration")
+	c.RailEasing = c.Meta.Properties.GetString("easing")
+	c.TargetNames = splitNameList(c.Meta.Properties.GetString("targets"))
+	c.FramePadding = GetFloatFromProperty(c.Meta, "padding")
+	c.MinZoom = GetFloatFromProperty(c.Meta, "minZoom")
+	c.MaxZoom = GetFloatFromProperty(c.Meta, "maxZoom")
+
+	if c.RailDuration == 0 {
+		c.RailDuration = 1
+	}
+
+	if c.MinZoom == 0 {
+		c.MinZoom = 0.25
+	}
+
+	if c.MaxZoom == 0 {
+		c.MaxZoom = 4
+	}
+
+	if c.FramePadding == 0 {
+		c.FramePadding = 32
+	}
+
+	if MainCamera == nil {
+		MainCamera = c
+	}
+
+	comp.Serialize = func(o *Object, enc *gob.Encoder) {
+		enc.Encode(&cameraData{
+			Follow:     o.FollowName,
+			Start:      "",
+			End:        "",
+			Speed:      o.Speed,
+			Progress:   o.Progress,
+			TargetZoom: o.TargetZoom,
+			ZoomSpeed:  o.ZoomSpeed,
+			Smoothing:  o.Smoothing,
+			Mode:       o.Mode,
+			First:      o.First,
+
+			DeadZone:     o.DeadZone,
+			LookAhead:    o.LookAhead,
+			Bounds:       o.Bounds,
+			HasBounds:    o.HasBounds,
+			BoundsName:   o.BoundsName,
+			RailName:     o.RailName,
+			RailDuration: o.RailDuration,
+			RailEasing:   o.RailEasing,
+			TargetNames:  o.TargetNames,
+			FramePadding: o.FramePadding,
+			MinZoom:      o.MinZoom,
+			MaxZoom:      o.MaxZoom,
+		})
+	}
+
+	comp.Deserialize = func(o *Object, dec *gob.Decoder) {
+		var dat cameraData
+		dec.Decode(&dat)
+
+		/* o.Follow = dat.Follow
+		o.Start = dat.Start
+		o.End = dat.End */
+		o.Speed = dat.Speed
+		o.Progress = dat.Progress
+		o.TargetZoom = dat.TargetZoom
+		o.ZoomSpeed = dat.ZoomSpeed
+		o.Smoothing = dat.Smoothing
+		o.Mode = dat.Mode
+		o.First = dat.First
+		o.FollowName = dat.Follow
+
+		o.DeadZone = dat.DeadZone
+		o.LookAhead = dat.LookAhead
+		o.Bounds = dat.Bounds
+		o.HasBounds = dat.HasBounds
+		o.BoundsName = dat.BoundsName
+		o.RailName = dat.RailName
+		o.RailDuration = dat.RailDuration
+		o.RailEasing = dat.RailEasing
+		o.TargetNames = dat.TargetNames
+		o.FramePadding = dat.FramePadding
+		o.MinZoom = dat.MinZoom
+		o.MaxZoom = dat.MaxZoom
+		o.rail = nil
+		o.Targets = nil
+	}
+
+	c.Speed = float32(spd)
+
+	comp.Draw = func(o *Object) {
+		if !DebugMode || !o.DebugVisible {
+			return
+		}
+
+		mode := "static"
+
+		switch o.Mode {
+		case 2:
+			mode = "follow"
+		case 3:
+			mode = "lerp"
+		case 4:
+			mode = "rail"
+		case 5:
+			mode = "frame"
+		}
+
+		if o.rail != nil {
+			o.rail.Draw(rl.Orange)
+		}
+
+		if o.HasBounds {
+			rl.DrawRectangleLinesEx(o.Bounds, 1, rl.Purple)
+		}
+
+		if o.DeadZone.X > 0 || o.DeadZone.Y > 0 {
+			rl.DrawRectangleLinesEx(rl.NewRectangle(
+				o.Position.X-o.DeadZone.X,
+				o.Position.Y-o.DeadZone.Y,
+				o.DeadZone.X*2,
+				o.DeadZone.Y*2,
+			), 1, rl.SkyBlue)
+		}
+
+		rl.DrawCircle(int32(o.Position.X), int32(o.Position.Y), 2, rl.White)
+		DrawTextCentered(
+			fmt.Sprintf("%s", o.Name),
+			int32(o.Position.X),
+			int32(o.Position.Y)+5,
+			10,
+			rl.White,
+		)
+
+		DrawTextCentered(
+			fmt.Sprintf("Mode: %s", mode),
+			int32(o.Position.X),
+			int32(o.Position.Y)+15,
+			10,
+			rl.White,
+		)
+	}
+}
+
+func finishCamera(c *Object) {
+	if c.Mode == CameraModeFollow {
+		c.Follow, _ = c.world.FindObject(c.FollowName)
+
+		if c.First && c.Follow != nil {
+			c.Position = c.Follow.Position
+		}
+	} else if c.Mode == CameraModeLerp {
+		c.Start, _ = c.world.FindObject(c.Meta.Properties.GetString("start"))
+		c.End, _ = c.world.FindObject(c.Meta.Properties.GetString("end"))
+	}
+
+	if c.BoundsName != "" {
+		c.SetCameraBounds(c.BoundsName)
+	}
+
+	if c.Mode == CameraModeRail && c.RailName != "" {
+		c.SetCameraRail(c.RailName, c.RailDuration, c.RailEasing)
+	}
+}
+
+func updateCamera(c *Object, dt float32) {
+	var dest rl.Vector2
+
+	CanSave = BitsSet(CanSave, IsSequenceHappening)
+
+	if c.Mode == CameraModeFollow {
+		if c.Follow == nil {
+			log.Println("Camera object follows nil reference.")
+			return
+		}
+
+		if c.Follow == LocalPlayer && !IsTimelinePlaying() {
+			CanSave = BitsClear(CanSave, IsSequenceHappening)
+		}
+
+		dest = Vector2Lerp(c.Position, c.getFollowGoal(dt), c.Smoothing)
+	} else if c.Mode == CameraModeLerp {
+		if c.Start == nil || c.End == nil {
+			log.Println("Camera object lerps between nil references.")
+			return
+		}
+
+		if c.First {
+			c.Position = c.Start.Position
+		}
+
+		dest = c.End.Position
+	} else if c.Mode == CameraModeRail {
+		if !c.resolveRail() {
+			log.Println("Camera object travels along a nil rail.")
+			return
+		}
+
+		dest = c.updateRail(dt)
+	} else if c.Mode == CameraModeFrame {
+		c.resolveTargets()
+
+		if len(c.Targets) == 0 {
+			log.Println("Camera object frames no targets.")
+			return
+		}
+
+		center, zoom := c.getFraming()
+		c.TargetZoom = zoom
+		dest = Vector2Lerp(c.Position, center, c.Smoothing)
+	} else {
+		dest = c.Position
+	}
+
+	dest.X = float32(math.Round(float64(dest.X)))
+	dest.Y = float32(math.Round(float64(dest.Y)))
+
+	if !c.First || c.Mode == CameraModeLerp {
+		t := c.Speed
+
+		if c.Mode == CameraModeLerp {
+			vd := raymath.Vector2Distance(dest, c.Position)
+
+			if c.Progress > 1 {
+				c.Progress = 1
+
+				c.Mode = CameraModeStatic
+
+				if c.End.EventName != "" {
+					FireEvent(c.End.EventName, c.End.EventArgs)
+				}
+			}
+
+			if t > vd {
+				t = vd
+			}
+
+			c.Position = Vector2Lerp(c.Start.Position, dest, c.Progress)
+			c.Progress += t * dt
+		} else {
+			c.Position = Vector2Lerp(c.Position, dest, t)
+		}
+	} else {
+		c.Position = dest
+	}
+
+	c.Zoom = ScalarLerp(c.Zoom, c.TargetZoom, c.ZoomSpeed*dt)
+
+	if c.HasBounds {
+		c.Position = c.clampToBounds(c.Position)
+	}
+
+	c.updateShake(dt)
+	c.First = false
+}
+
+// getFollowGoal applies the look-ahead and the dead zone onto the followed position
+func (c *Object) getFollowGoal(dt float32) rl.Vector2 {
+	goal := c.Follow.Position
+
+	if c.LookAhead != 0 {
+		ahead := c.Follow.Facing
+
+		if raymath.Vector2Length(ahead) > 0 {
+			raymath.Vector2Normalize(&ahead)
+			raymath.Vector2Scale(&ahead, c.LookAhead)
+		}
+
+		c.lookOffset = Vector2Lerp(c.lookOffset, ahead, float32(math.Min(1, float64(CameraLookAheadSpeed*dt))))
+		goal = raymath.Vector2Add(goal, c.lookOffset)
+	}
+
+	if c.DeadZone.X > 0 {
+		goal.X = applyDeadZone(c.Position.X, goal.X, c.DeadZone.X)
+	}
+
+	if c.DeadZone.Y > 0 {
+		goal.Y = applyDeadZone(c.Position.Y, goal.Y, c.DeadZone.Y)
+	}
+
+	return goal
+}
+
+// applyDeadZone keeps the camera still until the goal leaves the zone's extent
+func applyDeadZone(pos, goal, extent float32) float32 {
+	d := goal - pos
+
+	if d > extent {
+		return goal - extent
+	} else if d < -extent {
+		return goal + extent
+	}
+
+	return pos
+}
+
+// resolveRail builds the spline out of the rail object's polylines
+func (c *Object) resolveRail() bool {
+	if c.rail != nil {
+		return true
+	}
+
+	path, _ := c.world.FindObject(c.RailName)
+
+	if path == nil {
+		return false
+	}
+
+	points := GetPolyLinePoints(path)
+
+	if len(points) < 2 {
+		log.Printf("Camera rail '%s' needs a polyline with at least 2 points!\n", c.RailName)
+		return false
+	}
+
+	c.rail = NewSpline(points)
+	return true
+}
+
+// updateRail advances the camera along the rail and fires the rail's event at the end
+func (c *Object) updateRail(dt float32) rl.Vector2 {
+	if !c.First {
+		c.Progress += dt / c.RailDuration
+	}
+
+	if c.Progress >= 1 {
+		c.Progress = 1
+		c.Mode = CameraModeStatic
+
+		if path, _ := c.world.FindObject(c.RailName); path != nil && path.EventName != "" {
+			FireEvent(path.EventName, path.EventArgs)
+		}
+	}
+
+	return c.rail.PointAt(Ease(c.RailEasing, c.Progress))
+}
+
+// resolveTargets looks up the framed objects by their names
+func (c *Object) resolveTargets() {
+	if len(c.Targets) == len(c.TargetNames) {
+		return
+	}
+
+	c.Targets = []*Object{}
+
+	for _, v := range c.TargetNames {
+		if t, _ := c.world.FindObject(v); t != nil {
+			c.Targets = append(c.Targets, t)
+		}
+	}
+}
+
+// getFraming returns the center and the zoom keeping all targets on screen
+func (c *Object) getFraming() (rl.Vector2, float32) {
+	min := c.Targets[0].Position
+	max := min
+
+	for _, t := range c.Targets[1:] {
+		min.X = float32(math.Min(float64(min.X), float64(t.Position.X)))
+		min.Y = float32(math.Min(float64(min.Y), float64(t.Position.Y)))
+		max.X = float32(math.Max(float64(max.X), float64(t.Position.X)))
+		max.Y = float32(math.Max(float64(max.Y), float64(t.Position.Y)))
+	}
+
+	center := Vector2Lerp(min, max, 0.5)
+	width := max.X - min.X + c.FramePadding*2
+	height := max.Y - min.Y + c.FramePadding*2
+	zoom := c.MaxZoom
+
+	if width > 0 {
+		zoom = float32(math.Min(float64(zoom), float64(system.ScreenWidth)/float64(width)))
+	}
+
+	if height > 0 {
+		zoom = float32(math.Min(float64(zoom), float64(system.ScreenHeight)/float64(height)))
+	}
+
+	return center, float32(math.Max(float64(zoom), float64(c.MinZoom)))
+}
+
+// clampToBounds keeps the view within the bounds, views larger than the bounds get centered
+func (c *Object) clampToBounds(p rl.Vector2) rl.Vector2 {
+	zoom := c.Zoom
+
+	if zoom <= 0 {
+		zoom = 1
+	}
+
+	p.X = clampViewAxis(p.X, c.Bounds.X, c.Bounds.Width, float32(system.ScreenWidth)/2/zoom)
+	p.Y = clampViewAxis(p.Y, c.Bounds.Y, c.Bounds.Height, float32(system.ScreenHeight)/2/zoom)
+
+	return p
+}
+
+func clampViewAxis(v, start, size, half float32) float32 {
+	if size <= half*2 {
+		return start + size/2
+	}
+
+	return float32(math.Max(float64(start+half), math.Min(float64(start+size-half), float64(v))))
+}
+
+// updateShake derives the shake from the trauma, the shake grows with the square of the trauma
+func (c *Object) updateShake(dt float32) {
+	if c.Trauma <= 0 {
+		c.ShakeOffset = rl.Vector2{}
+		c.ShakeAngle = 0
+		return
+	}
+
+	c.shakeTime += dt
+	shake := c.Trauma * c.Trauma
+	t := c.shakeTime * CameraShakeFrequency
+
+	c.ShakeOffset = rl.Vector2{
+		X: CameraShakeMaxOffset * shake * shakeNoise(t, 0),
+		Y: CameraShakeMaxOffset * shake * shakeNoise(t, 1),
+	}
+	c.ShakeAngle = CameraShakeMaxAngle * shake * shakeNoise(t, 2)
+
+	c.Trauma = float32(math.Max(0, float64(c.Trauma-CameraShakeDecay*dt)))
+}
+
+// shakeNoise is a smooth pseudo-random signal in range -1..1, each seed gives a different signal
+func shakeNoise(t float32, seed int) float32 {
+	s := float64(seed) * 17.31
+	x := float64(t)
+
+	return float32(math.Sin(x+s)*0.5 + math.Sin(x*2.13+s*1.7)*0.3 + math.Sin(x*4.37+s*2.9)*0.2)
+}
+
+// AddCameraTrauma shakes the camera, the trauma accumulates up to 1 and decays over time
+func (c *Object) AddCameraTrauma(amount float32) {
+	c.Trauma = float32(math.Min(1, math.Max(0, float64(c.Trauma+amount))))
+}
+
+// SetCameraRail makes the camera travel along the polyline of the named object
+func (c *Object) SetCameraRail(name string, duration float32, easing string) {
+	if duration <= 0 {
+		duration = 1
+	}
+
+	c.RailName = name
+	c.RailDuration = duration
+	c.RailEasing = easing
+	c.Mode = CameraModeRail
+	c.Progress = 0
+	c.First = true
+	c.rail = nil
+
+	if !c.resolveRail() {
+		log.Printf("Camera rail '%s' could not be found!\n", name)
+	}
+}
+
+// SetCameraTargets makes the camera keep all of the named objects on screen
+func (c *Object) SetCameraTargets(names []string) {
+	c.TargetNames = names
+	c.Targets = nil
+	c.Mode = CameraModeFrame
+}
+
+// SetCameraFollow makes the camera follow the object
+func (c *Object) SetCameraFollow(target *Object) {
+	c.Mode = CameraModeFollow
+	c.Follow = target
+
+	if target != nil {
+		c.FollowName = target.Name
+	}
+}
+
+// SetCameraBounds clamps the camera to the whole map when the name is "map", or to the area of the named object
+func (c *Object) SetCameraBounds(name string) {
+	c.BoundsName = name
+	c.HasBounds = false
+
+	if name == "map" {
+		c.Bounds, c.HasBounds = getMapBounds(c.world)
+		return
+	}
+
+	area, _ := c.world.FindObject(name)
+
+	if area == nil {
+		log.Printf("Camera bounds '%s' could not be found!\n", name)
+		return
+	}
+
+	if area.Meta != nil && area.Meta.Width > 0 && area.Meta.Height > 0 {
+		c.Bounds = rl.NewRectangle(area.Position.X, area.Position.Y, float32(area.Meta.Width), float32(area.Meta.Height))
+	} else {
+		c.Bounds = area.GetAABB(area).ToFloat32()
+	}
+
+	c.HasBounds = true
+}
+
+// SetCameraBoundsRect clamps the camera to the rectangle
+func (c *Object) SetCameraBoundsRect(bounds rl.Rectangle) {
+	c.BoundsName = ""
+	c.Bounds = bounds
+	c.HasBounds = true
+}
+
+// ClearCameraBounds lets the camera move freely
+func (c *Object) ClearCameraBounds() {
+	c.BoundsName = ""
+	c.HasBounds = false
+}
+
+func getMapBounds(w *World) (rl.Rectangle, bool) {
+	for _, m := range Maps {
+		if m.World == w && m.tilemap != nil {
+			return rl.NewRectangle(
+				0,
+				0,
+				float32(m.tilemap.Width*m.tilemap.TileWidth),
+				float32(m.tilemap.Height*m.tilemap.TileHeight),
+			), true
+		}
+	}
+
+	return rl.Rectangle{}, false
+}
+
+// SetCameraZoom overrides camera zoom
+func (c *Object) SetCameraZoom(t float32) {
+	c.Zoom = t
+	c.TargetZoom = t
+}
+
+// SetCameraMode sets the camera behavior mode
+func (c *Object) SetCameraMode(strMode string) {
+	switch strMode {
+	default:
+		fallthrough
+	case "static":
+		c.Mode = 1
+	case "follow":
+		c.Mode = 2
+	case "lerp":
+		c.Mode = 3
+	case "rail":
+		c.Mode = 4
+	case "frame":
+		c.Mode = 5
+	}
+}
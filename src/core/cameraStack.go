@@ -0,0 +1,132 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/raylib-go/raymath"
+	"github.com/zaklaus/rurik/src/system"
+)
+
+var (
+	// CameraBlendEasing is the easing used when blending between cameras
+	CameraBlendEasing = "sineInOut"
+
+	cameraStack []*Object
+	cameraBlend cameraBlendState
+)
+
+type cameraBlendState struct {
+	From     rl.Vector2
+	FromZoom float32
+	Time     float32
+	Duration float32
+}
+
+// PushCamera makes the camera the main one, the view blends over from the previous camera within the duration in seconds
+func PushCamera(cam *Object, duration float32) {
+	if cam == nil || cam == MainCamera {
+		return
+	}
+
+	if MainCamera != nil && MainCamera.Name != "TempCamera__" {
+		cameraStack = append(cameraStack, MainCamera)
+	}
+
+	startCameraBlend(duration)
+	MainCamera = cam
+}
+
+// PopCamera returns to the previously pushed camera, the view blends back within the duration in seconds
+func PopCamera(duration float32) {
+	if len(cameraStack) == 0 {
+		return
+	}
+
+	startCameraBlend(duration)
+	MainCamera = cameraStack[len(cameraStack)-1]
+	cameraStack = cameraStack[:len(cameraStack)-1]
+}
+
+// IsCameraBlending checks whether the view is still blending between cameras
+func IsCameraBlending() bool {
+	return cameraBlend.Time < cameraBlend.Duration
+}
+
+// GetCameraView returns the position and the zoom of the rendered view
+func GetCameraView() (rl.Vector2, float32) {
+	if MainCamera == nil {
+		return rl.Vector2{}, 1
+	}
+
+	pos := MainCamera.Position
+	zoom := MainCamera.Zoom
+
+	if zoom <= 0 {
+		zoom = 1
+	}
+
+	if IsCameraBlending() {
+		t := Ease(CameraBlendEasing, cameraBlend.Time/cameraBlend.Duration)
+		pos = Vector2Lerp(cameraBlend.From, pos, t)
+		zoom = ScalarLerp(cameraBlend.FromZoom, zoom, t)
+	}
+
+	return pos, zoom
+}
+
+func startCameraBlend(duration float32) {
+	pos, zoom := GetCameraView()
+
+	cameraBlend = cameraBlendState{
+		From:     pos,
+		FromZoom: zoom,
+		Duration: duration,
+	}
+}
+
+func flushCameraStack() {
+	cameraStack = nil
+	cameraBlend = cameraBlendState{}
+}
+
+// updateCameraView advances the blend and applies the view onto the render camera
+func updateCameraView(dt float32) {
+	if IsCameraBlending() {
+		cameraBlend.Time += dt
+	}
+
+	if MainCamera == nil {
+		return
+	}
+
+	pos, zoom := GetCameraView()
+	pos = raymath.Vector2Add(pos, MainCamera.ShakeOffset)
+
+	RenderCamera.Zoom = zoom
+	RenderCamera.Rotation = MainCamera.ShakeAngle
+	RenderCamera.Offset = rl.Vector2{
+		X: float32(int(system.ScreenWidth / 2)),
+		Y: float32(int(system.ScreenHeight / 2)),
+	}
+
+	// NOTE: keeps the view pixel-aligned
+	RenderCamera.Target = rl.Vector2{
+		X: float32(int(pos.X*zoom)) / zoom,
+		Y: float32(int(pos.Y*zoom)) / zoom,
+	}
+}
@@ -0,0 +1,257 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"log"
+	"math"
+	"sort"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/rurik/src/system"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// MinutesPerDay is the length of an in-game day
+	MinutesPerDay = 24 * 60
+)
+
+var (
+	// DayCycleFile describes the stages of the day and the length of the in-game day
+	DayCycleFile = "misc/daycycle.yaml"
+
+	// GameClock is the in-game time shared by all maps
+	GameClock Clock
+
+	// clockHour is the last hour the events were fired for, counted since the start of the first day
+	clockHour int
+
+	dayCycle *DayCycle
+)
+
+// Clock represents the in-game time
+type Clock struct {
+	Day     int
+	Minutes float64
+}
+
+// DayCycle describes the stages the day goes through
+type DayCycle struct {
+	// DayLength is the real-time length of the in-game day in minutes
+	DayLength float64     `yaml:"dayLength"`
+	StartHour float64     `yaml:"startHour"`
+	Stages    []*DayStage `yaml:"stages"`
+}
+
+// DayStage is a part of the day starting at the given hour,
+// the sky changes to the stage's color over the transition time in hours
+type DayStage struct {
+	Name       string  `yaml:"name"`
+	Hour       float64 `yaml:"hour"`
+	Color      string  `yaml:"color"`
+	Transition float64 `yaml:"transition"`
+	Night      bool    `yaml:"night"`
+
+	color rl.Vector3
+}
+
+// Hour returns the current hour of the day
+func (c *Clock) Hour() int {
+	return int(c.Minutes / 60)
+}
+
+// hours returns the number of hours passed since the start of the first day
+func (c *Clock) hours() int {
+	return c.Day*24 + c.Hour()
+}
+
+// Minute returns the current minute of the hour
+func (c *Clock) Minute() int {
+	return int(c.Minutes) % 60
+}
+
+// SetTime sets the time of the current day
+func (c *Clock) SetTime(hour, minute int) {
+	c.Minutes = math.Mod(float64(hour*60+minute), MinutesPerDay)
+}
+
+// ResetClock sets the clock to the beginning of the first day
+func ResetClock() {
+	cycle := GetDayCycle()
+
+	GameClock = Clock{
+		Day:     1,
+		Minutes: cycle.StartHour * 60,
+	}
+
+	syncClock()
+}
+
+// syncClock makes the events continue from the current time, the hours before it are skipped
+func syncClock() {
+	clockHour = GameClock.hours()
+}
+
+// GetDayCycle returns the day cycle, loading it on the first use
+func GetDayCycle() *DayCycle {
+	if dayCycle == nil {
+		dayCycle = loadDayCycle()
+	}
+
+	return dayCycle
+}
+
+// GetDayStage returns the current stage of the day and the one preceding it
+func GetDayStage() (stage, previous *DayStage) {
+	stages := GetDayCycle().Stages
+	hour := GameClock.Minutes / 60
+	index := len(stages) - 1
+
+	for i, v := range stages {
+		if v.Hour <= hour {
+			index = i
+		}
+	}
+
+	return stages[index], stages[(index+len(stages)-1)%len(stages)]
+}
+
+// IsNight checks whether the current stage of the day is at night
+func IsNight() bool {
+	stage, _ := GetDayStage()
+	return stage.Night
+}
+
+// GetDayCycleColor returns the sky color at the current time of the day
+func GetDayCycleColor() rl.Vector3 {
+	stage, previous := GetDayStage()
+	elapsed := math.Mod(GameClock.Minutes/60-stage.Hour+24, 24)
+
+	if stage.Transition <= 0 || elapsed >= stage.Transition {
+		return stage.color
+	}
+
+	return LerpColor(previous.color, stage.color, elapsed/stage.Transition)
+}
+
+// updateClock advances the clock and fires the time of the day events
+func updateClock(dt float64) {
+	cycle := GetDayCycle()
+
+	if cycle.DayLength <= 0 {
+		return
+	}
+
+	lastStage, _ := GetDayStage()
+
+	GameClock.Minutes += dt * MinutesPerDay / (cycle.DayLength * 60)
+
+	for GameClock.Minutes >= MinutesPerDay {
+		GameClock.Minutes -= MinutesPerDay
+		GameClock.Day++
+	}
+
+	// NOTE: an update can cross several hours, e.g. after setTime or with a large time scale,
+	// every one of them gets its events, turning the clock back fires none
+	for clockHour < GameClock.hours() {
+		clockHour++
+
+		if clockHour%24 == 0 {
+			fireClockEvent("onNewDay", float64(clockHour/24))
+		}
+
+		fireClockEvent("onHour", float64(clockHour%24))
+	}
+
+	if clockHour > GameClock.hours() {
+		syncClock()
+	}
+
+	stage, _ := GetDayStage()
+
+	if stage == lastStage {
+		return
+	}
+
+	FireEvent("onDayStage", stage.Name)
+
+	if stage.Night && !lastStage.Night {
+		fireClockEvent("onNightfall")
+	} else if !stage.Night && lastStage.Night {
+		fireClockEvent("onDaybreak")
+	}
+}
+
+// fireClockEvent notifies both scripts and quests
+func fireClockEvent(name string, args ...float64) {
+	data := []interface{}{}
+
+	for _, v := range args {
+		data = append(data, v)
+	}
+
+	FireEvent(name, data...)
+	Quests.CallEvent(-1, name, args)
+}
+
+func loadDayCycle() *DayCycle {
+	cycle := defaultDayCycle()
+	asset := system.FindAsset(DayCycleFile)
+
+	if asset != nil {
+		custom := &DayCycle{}
+
+		if err := yaml.Unmarshal(asset.Data, custom); err != nil {
+			log.Printf("Day cycle could not be loaded: %s\n", err.Error())
+		} else if len(custom.Stages) == 0 {
+			log.Println("Day cycle has no stages!")
+		} else {
+			cycle = custom
+		}
+	}
+
+	sort.SliceStable(cycle.Stages, func(i, j int) bool {
+		return cycle.Stages[i].Hour < cycle.Stages[j].Hour
+	})
+
+	for _, v := range cycle.Stages {
+		color, err := GetColorFromHex(v.Color)
+
+		if err != nil {
+			log.Printf("Day stage '%s' has an invalid color '%s'!\n", v.Name, v.Color)
+			color = ColorToVec3(rl.White)
+		}
+
+		v.color = color
+	}
+
+	return cycle
+}
+
+func defaultDayCycle() *DayCycle {
+	return &DayCycle{
+		DayLength: 24,
+		StartHour: 8,
+		Stages: []*DayStage{
+			{Name: "sunrise", Hour: 5, Color: "#fffff999", Transition: 2},
+			{Name: "day", Hour: 8, Color: "#ffe5fffa", Transition: 2},
+			{Name: "dusk", Hour: 18, Color: "#ffffc0a0", Transition: 2},
+			{Name: "night", Hour: 21, Color: "#ff58589f", Transition: 2, Night: true},
+		},
+	}
+}
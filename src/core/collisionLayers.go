@@ -0,0 +1,321 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zaklaus/rurik/src/system"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// MaxCollisionLayers is the amount of collision types usable as layers
+	MaxCollisionLayers = 32
+)
+
+var (
+	// CollisionLayersFile describes custom layers and the pairs of layers ignoring each other
+	CollisionLayersFile = "misc/collision.yaml"
+
+	// collisionMatrix holds a mask of layers each layer collides with,
+	// the row of CollisionNone is used by objects not belonging to any layer
+	collisionMatrix [MaxCollisionLayers]uint32
+
+	isCollisionMatrixCollapsed = true
+)
+
+type collisionLayersConfig struct {
+	Layers []string   `yaml:"layers"`
+	Ignore [][]string `yaml:"ignore"`
+}
+
+// LayerBit returns the mask bit of a collision type
+func LayerBit(col uint32) uint32 {
+	if col == CollisionNone || col >= MaxCollisionLayers {
+		return 0
+	}
+
+	return 1 << col
+}
+
+// SetLayerCollision enables or disables collisions between two layers
+func SetLayerCollision(a, b uint32, enabled bool) {
+	// NOTE: the row of CollisionNone is the only one-way row
+	if b == CollisionNone {
+		a, b = b, a
+	}
+
+	setLayerMaskBit(a, b, enabled)
+
+	if a != CollisionNone {
+		setLayerMaskBit(b, a, enabled)
+	}
+}
+
+// LayersCollide reports whether the two layers collide with each other
+func LayersCollide(a, b uint32) bool {
+	return GetLayerMask(a)&LayerBit(b) != 0
+}
+
+// GetLayerMask returns the mask of layers the layer collides with
+func GetLayerMask(col uint32) uint32 {
+	if col >= MaxCollisionLayers {
+		return 0
+	}
+
+	return collisionMatrix[col]
+}
+
+// RetrieveCollisionMask converts collision type names into a layer mask
+func RetrieveCollisionMask(names []string) uint32 {
+	var mask uint32
+
+	for _, v := range names {
+		mask |= LayerBit(RetrieveCollisionType(v))
+	}
+
+	return mask
+}
+
+// GetCollisionTypeName retrieves the name of a collision type
+func GetCollisionTypeName(col uint32) string {
+	for k := range collisionTypes {
+		if collisionTypes[k] == col {
+			return collisionTypeNames[k]
+		}
+	}
+
+	return fmt.Sprintf("%d", col)
+}
+
+// GetCollisionLayers returns the mask of layers the object belongs to
+func (o *Object) GetCollisionLayers() uint32 {
+	return LayerBit(o.CollisionType) | o.CollisionLayers
+}
+
+// GetCollisionMask returns the mask of layers the object collides with,
+// objects without an explicit mask use the matrix rows of their layers
+func (o *Object) GetCollisionMask() uint32 {
+	if o.CollisionMask != 0 {
+		return o.CollisionMask
+	}
+
+	layers := o.GetCollisionLayers()
+
+	if layers == 0 {
+		return collisionMatrix[CollisionNone]
+	}
+
+	var mask uint32
+
+	for i := uint32(1); i < MaxCollisionLayers; i++ {
+		if layers&LayerBit(i) != 0 {
+			mask |= collisionMatrix[i]
+		}
+	}
+
+	return mask
+}
+
+// parseCollisionLayers returns the primary collision type and the extra layers of a ";"-separated colType value
+func parseCollisionLayers(value string) (uint32, uint32) {
+	names := splitCollisionTypeNames(value)
+
+	if len(names) == 0 {
+		return CollisionNone, 0
+	}
+
+	return RetrieveCollisionType(names[0]), RetrieveCollisionMask(names[1:])
+}
+
+// splitCollisionTypeNames splits a ";"-separated list of layers, the same way as the other list properties
+func splitCollisionTypeNames(value string) []string {
+	names := []string{}
+
+	for _, v := range strings.Split(value, ";") {
+		v = strings.TrimSpace(v)
+
+		if v != "" {
+			names = append(names, v)
+		}
+	}
+
+	return names
+}
+
+func setLayerMaskBit(row, col uint32, enabled bool) {
+	if row >= MaxCollisionLayers {
+		return
+	}
+
+	if enabled {
+		collisionMatrix[row] |= LayerBit(col)
+	} else {
+		collisionMatrix[row] &^= LayerBit(col)
+	}
+}
+
+// resetCollisionMatrix makes every layer collide with all the others,
+// objects outside of layers don't collide with slopes by default
+func resetCollisionMatrix() {
+	for i := range collisionMatrix {
+		collisionMatrix[i] = ^LayerBit(CollisionNone)
+	}
+
+	collisionMatrix[CollisionNone] &^= LayerBit(CollisionSlope)
+}
+
+// loadCollisionLayers registers custom layers and applies the ignored pairs from the config file
+func loadCollisionLayers() {
+	asset := system.FindAsset(CollisionLayersFile)
+
+	if asset == nil {
+		return
+	}
+
+	var cfg collisionLayersConfig
+
+	if err := yaml.Unmarshal(asset.Data, &cfg); err != nil {
+		log.Printf("Collision layers could not be loaded: %s\n", err.Error())
+		return
+	}
+
+	for _, v := range cfg.Layers {
+		if RetrieveCollisionType(v) != CollisionNone || v == "none" {
+			continue
+		}
+
+		col := nextCollisionType()
+
+		if col >= MaxCollisionLayers {
+			log.Printf("Collision layer '%s' exceeds the limit of %d layers!\n", v, MaxCollisionLayers)
+			continue
+		}
+
+		AddCollisionType(v, col)
+	}
+
+	for _, pair := range cfg.Ignore {
+		if len(pair) != 2 {
+			log.Printf("Collision layer pair %v has to contain exactly 2 layers!\n", pair)
+			continue
+		}
+
+		SetLayerCollision(RetrieveCollisionType(pair[0]), RetrieveCollisionType(pair[1]), false)
+	}
+}
+
+// SaveCollisionLayers writes the current layer matrix into the loose files directory
+func SaveCollisionLayers() error {
+	cfg := collisionLayersConfig{
+		Layers: []string{},
+		Ignore: [][]string{},
+	}
+
+	for k, v := range collisionTypes {
+		if v >= FirstCollisionType {
+			cfg.Layers = append(cfg.Layers, collisionTypeNames[k])
+		}
+	}
+
+	for _, a := range collisionTypes {
+		for _, b := range collisionTypes {
+			if b == CollisionNone || (a != CollisionNone && b < a) || LayersCollide(a, b) {
+				continue
+			}
+
+			cfg.Ignore = append(cfg.Ignore, []string{GetCollisionTypeName(a), GetCollisionTypeName(b)})
+		}
+	}
+
+	data, err := yaml.Marshal(&cfg)
+
+	if err != nil {
+		return err
+	}
+
+	fileName := filepath.Join(system.LooseFilesDir, CollisionLayersFile)
+
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(fileName, data, 0644)
+}
+
+// nextCollisionType returns the first unused custom collision type
+func nextCollisionType() uint32 {
+	col := FirstCollisionType
+
+	for _, v := range collisionTypes {
+		if v >= col {
+			col = v + 1
+		}
+	}
+
+	return col
+}
+
+// drawCollisionLayersUI lists the layer matrix in the editor, each button toggles a pair of layers
+func drawCollisionLayersUI(parent *EditorElement) {
+	matrixNode := PushEditorElement(parent, "collision layers", &isCollisionMatrixCollapsed)
+
+	if isCollisionMatrixCollapsed {
+		return
+	}
+
+	for _, a := range collisionTypes {
+		row := PushEditorElement(matrixNode, GetCollisionTypeName(a), nil)
+
+		for _, b := range collisionTypes {
+			if b == CollisionNone {
+				continue
+			}
+
+			rowLayer, colLayer := a, b
+			state := "-"
+
+			if LayersCollide(rowLayer, colLayer) {
+				state = "x"
+			}
+
+			SetUpButton(
+				PushEditorElement(row, fmt.Sprintf("%s: %s", GetCollisionTypeName(colLayer), state), nil),
+				func() {
+					SetLayerCollision(rowLayer, colLayer, !LayersCollide(rowLayer, colLayer))
+				},
+				true,
+			)
+		}
+	}
+
+	SetUpButton(
+		PushEditorElement(matrixNode, "Save Layers", nil),
+		func() {
+			if err := SaveCollisionLayers(); err != nil {
+				log.Printf("Collision layers could not be saved: %s\n", err.Error())
+			}
+		},
+		false,
+	)
+}
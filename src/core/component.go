@@ -0,0 +1,294 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Component is a reusable piece of object logic, objects are composed of components.
+// Hooks are invoked after the object's own callbacks in the order the components were added
+type Component struct {
+	Name string
+
+	// Hooks
+	Init        func(o *Object)
+	Finish      func(o *Object)
+	Update      func(o *Object, dt float32)
+	Draw        func(o *Object)
+	DrawNormals func(o *Object)
+	DebugDraw   func(o *Object)
+	Trigger     func(o, inst *Object)
+	Serialize   func(o *Object, enc *gob.Encoder)
+	Deserialize func(o *Object, dec *gob.Decoder)
+
+	// Batched components draw only through DrawSprite, so their sprites can be grouped with other objects
+	Batched bool
+}
+
+// ComponentCtor sets up the component's hooks and the object's data it relies on
+type ComponentCtor func(o *Object, c *Component)
+
+type componentData struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+var (
+	componentCtors = make(map[string]ComponentCtor)
+	presets        = make(map[string][]string)
+)
+
+// RegisterComponent adds a new component type
+func RegisterComponent(name string, ctor ComponentCtor) error {
+	_, ok := componentCtors[name]
+
+	if ok {
+		return fmt.Errorf("can't register already existing component '%s'", name)
+	}
+
+	componentCtors[name] = ctor
+
+	return nil
+}
+
+// RegisterPreset adds a new object class composed of the listed components
+func RegisterPreset(class string, components ...string) error {
+	_, isPreset := presets[class]
+	_, isClass := objCtors[class]
+
+	if isPreset || isClass {
+		return fmt.Errorf("can't register already existing class '%s'", class)
+	}
+
+	presets[class] = components
+
+	return nil
+}
+
+// GetPresetComponents returns the components the class is composed of
+func GetPresetComponents(class string) []string {
+	return presets[class]
+}
+
+// ApplyPreset adds all components of the preset to the object
+func (o *Object) ApplyPreset(class string) error {
+	components, ok := presets[class]
+
+	if !ok {
+		return fmt.Errorf("preset '%s' is undefined", class)
+	}
+
+	for _, v := range components {
+		if _, err := o.AddComponent(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddComponent attaches a new component to the object, the same component can be attached only once
+func (o *Object) AddComponent(name string) (*Component, error) {
+	if c := o.GetComponent(name); c != nil {
+		return c, nil
+	}
+
+	ctor, ok := componentCtors[name]
+
+	if !ok {
+		return nil, fmt.Errorf("component '%s' is undefined", name)
+	}
+
+	c := &Component{Name: name}
+	ctor(o, c)
+	o.Components = append(o.Components, c)
+
+	return c, nil
+}
+
+// GetComponent returns the attached component
+func (o *Object) GetComponent(name string) *Component {
+	for _, c := range o.Components {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// HasComponent checks whether the component is attached to the object
+func (o *Object) HasComponent(name string) bool {
+	return o.GetComponent(name) != nil
+}
+
+// RemoveComponent detaches the component from the object
+func (o *Object) RemoveComponent(name string) {
+	for i, c := range o.Components {
+		if c.Name == name {
+			o.Components = append(o.Components[:i], o.Components[i+1:]...)
+			return
+		}
+	}
+}
+
+// addPropertyComponents attaches the components listed in the "components" property
+func (o *Object) addPropertyComponents() {
+	for _, v := range splitNameList(o.Meta.Properties.GetString("components")) {
+		if _, err := o.AddComponent(v); err != nil {
+			log.Printf("Object '%s' can't add component: %s!\n", o.Name, err.Error())
+		}
+	}
+}
+
+func splitNameList(value string) (names []string) {
+	for _, v := range strings.Split(value, ";") {
+		v = strings.TrimSpace(v)
+
+		if v != "" {
+			names = append(names, v)
+		}
+	}
+
+	return names
+}
+
+func (o *Object) initComponents() {
+	for _, c := range o.Components {
+		if c.Init != nil {
+			c.Init(o)
+		}
+	}
+}
+
+func (o *Object) finishComponents() {
+	for _, c := range o.Components {
+		if c.Finish != nil {
+			c.Finish(o)
+		}
+	}
+}
+
+func (o *Object) updateComponents(dt float32) {
+	for _, c := range o.Components {
+		if c.Update != nil {
+			c.Update(o, dt)
+		}
+	}
+}
+
+func (o *Object) drawComponents() {
+	for _, c := range o.Components {
+		if c.Draw != nil {
+			if !c.Batched {
+				FlushSpriteBatch()
+			}
+
+			c.Draw(o)
+		}
+	}
+}
+
+// drawNormalComponents draws the object's normal maps into the normal buffer
+func (o *Object) drawNormalComponents() {
+	for _, c := range o.Components {
+		if c.DrawNormals != nil {
+			if !c.Batched {
+				FlushSpriteBatch()
+			}
+
+			c.DrawNormals(o)
+		}
+	}
+}
+
+func (o *Object) debugDrawComponents() {
+	for _, c := range o.Components {
+		if c.DebugDraw != nil {
+			c.DebugDraw(o)
+		}
+	}
+}
+
+// triggerComponents is the default trigger of objects
+func triggerComponents(o, inst *Object) {
+	for _, c := range o.Components {
+		if c.Trigger != nil {
+			c.Trigger(o, inst)
+		}
+	}
+}
+
+func (o *Object) serializeComponents() []componentData {
+	data := []componentData{}
+
+	for _, c := range o.Components {
+		if c.Serialize == nil {
+			continue
+		}
+
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		c.Serialize(o, enc)
+
+		data = append(data, componentData{
+			Name: c.Name,
+			Data: buf.Bytes(),
+		})
+	}
+
+	return data
+}
+
+func (o *Object) deserializeComponents(data []componentData) {
+	for _, v := range data {
+		c, err := o.AddComponent(v.Name)
+
+		if err != nil {
+			log.Printf("Object '%s' can't restore component: %s!\n", o.Name, err.Error())
+			continue
+		}
+
+		if c.Deserialize == nil {
+			continue
+		}
+
+		dec := gob.NewDecoder(bytes.NewBuffer(v.Data))
+		c.Deserialize(o, dec)
+	}
+}
+
+func initDefaultComponents() {
+	RegisterComponent("sprite", newSpriteComponent)
+	RegisterComponent("animator", newAnimatorComponent)
+	RegisterComponent("collider", newColliderComponent)
+	RegisterComponent("body", newBodyComponent)
+	RegisterComponent("light", newLightComponent)
+	RegisterComponent("trigger", newTriggerComponent)
+	RegisterComponent("script", newScriptComponent)
+	RegisterComponent("camera", newCameraComponent)
+	RegisterComponent("timer", newTimerComponent)
+	RegisterComponent("marker", newMarkerComponent)
+	RegisterComponent("tile", newTileComponent)
+	RegisterComponent("npc", newNPCComponent)
+	RegisterComponent("emitter", newEmitterComponent)
+}
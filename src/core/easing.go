@@ -0,0 +1,93 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"log"
+	"math"
+)
+
+// EasingFunc maps the linear progress in range 0..1 onto the eased one
+type EasingFunc func(t float32) float32
+
+var (
+	easings = map[string]EasingFunc{
+		"linear":  func(t float32) float32 { return t },
+		"quadIn":  func(t float32) float32 { return t * t },
+		"quadOut": func(t float32) float32 { return t * (2 - t) },
+		"quadInOut": func(t float32) float32 {
+			if t < 0.5 {
+				return 2 * t * t
+			}
+
+			return -1 + (4-2*t)*t
+		},
+		"cubicIn": func(t float32) float32 { return t * t * t },
+		"cubicOut": func(t float32) float32 {
+			t--
+			return t*t*t + 1
+		},
+		"cubicInOut": func(t float32) float32 {
+			if t < 0.5 {
+				return 4 * t * t * t
+			}
+
+			t = 2*t - 2
+			return 0.5*t*t*t + 1
+		},
+		"sineIn": func(t float32) float32 {
+			return 1 - float32(math.Cos(float64(t)*math.Pi/2))
+		},
+		"sineOut": func(t float32) float32 {
+			return float32(math.Sin(float64(t) * math.Pi / 2))
+		},
+		"sineInOut": func(t float32) float32 {
+			return 0.5 * (1 - float32(math.Cos(float64(t)*math.Pi)))
+		},
+		"smoothstep": func(t float32) float32 { return t * t * (3 - 2*t) },
+	}
+)
+
+// RegisterEasing adds a custom easing function
+func RegisterEasing(name string, fn EasingFunc) {
+	easings[name] = fn
+}
+
+// Ease applies the named easing onto the progress, unknown names fall back to linear
+func Ease(name string, t float32) float32 {
+	if t <= 0 {
+		return 0
+	}
+
+	if t >= 1 {
+		return 1
+	}
+
+	if name == "" {
+		return t
+	}
+
+	fn, ok := easings[name]
+
+	if !ok {
+		log.Printf("Easing '%s' is undefined!\n", name)
+		easings[name] = easings["linear"]
+		return t
+	}
+
+	return fn(t)
+}
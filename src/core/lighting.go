@@ -53,6 +53,7 @@ func UpdateLightingSolution() {
 	lightingProfiler.StartInvocation()
 	populateAdditiveLayer()
 	populateMultiplicativeLight()
+	applyNormalLighting()
 	lightingProfiler.StopInvocation()
 	lmState := rl.BlendMultiplied
 
@@ -66,6 +67,7 @@ func UpdateLightingSolution() {
 
 func populateAdditiveLayer() {
 	objs := CurrentMap.World.Objects
+	isNight := IsNight()
 
 	rl.BeginTextureMode(additiveLightTexture)
 	{
@@ -73,13 +75,13 @@ func populateAdditiveLayer() {
 		rl.BeginMode2D(RenderCamera)
 		{
 			for _, o := range objs {
-				if !o.HasSpecularLight || !o.Visible {
+				if !o.HasSpecularLight || !o.Visible || (o.NightOnly && !isNight) || !isLightWithinFrustum(o, o.Radius) {
 					continue
 				}
 
-				in := o.Color
-				in.A = 90
-				out := o.Color
+				in := o.getLight().getColor(o)
+				in.A = uint8(float32(in.A) / 255 * 90)
+				out := in
 				out.A = 0
 				rl.DrawCircleGradient(
 					int32(o.Position.X+o.Offset.X),
@@ -89,6 +91,8 @@ func populateAdditiveLayer() {
 					out,
 				)
 			}
+
+			drawParticleLights(CurrentMap.World)
 		}
 		rl.EndMode2D()
 	}
@@ -99,6 +103,7 @@ func populateAdditiveLayer() {
 
 func populateMultiplicativeLight() {
 	objs := CurrentMap.World.Objects
+	isNight := IsNight()
 
 	rl.BeginTextureMode(multiplicativeLightTexture)
 	{
@@ -109,17 +114,11 @@ func populateMultiplicativeLight() {
 			rl.BeginMode2D(RenderCamera)
 			{
 				for _, o := range objs {
-					if !o.HasLight || !o.Visible {
+					if !o.HasLight || !o.Visible || (o.NightOnly && !isNight) || !isLightWithinFrustum(o, o.Attenuation) {
 						continue
 					}
 
-					rl.DrawCircleGradient(
-						int32(o.Position.X+o.Offset.X),
-						int32(o.Position.Y+o.Offset.Y),
-						o.Attenuation,
-						o.Color,
-						rl.Blank,
-					)
+					drawLight(o)
 				}
 			}
 			rl.EndMode2D()
@@ -129,3 +128,27 @@ func populateMultiplicativeLight() {
 	rl.EndTextureMode()
 	BlurRenderTarget(multiplicativeLightTexture, 32)
 }
+
+// newLightComponent makes the object emit light, specular highlights are enabled by the "specular" property.
+// Lights with the "nightOnly" property are turned off during the day, "shadows" set to 0 lets the light pass through walls.
+// The light's type and animation are described in getLightFromProperty
+func newLightComponent(o *Object, c *Component) {
+	o.HasLight = true
+
+	if o.Color == rl.Blank {
+		o.Color = rl.White
+	}
+
+	if o.Attenuation == 0 {
+		o.Attenuation = 64
+	}
+
+	c.DebugDraw = func(o *Object) {
+		pos := rl.Vector2{X: o.Position.X + o.Offset.X, Y: o.Position.Y + o.Offset.Y}
+		drawLightDebug(o)
+
+		if o.HasSpecularLight {
+			rl.DrawCircleLines(int32(pos.X), int32(pos.Y), o.Radius, rl.Fade(o.Color, 0.5))
+		}
+	}
+}
@@ -0,0 +1,311 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"log"
+	"math"
+	"math/rand"
+
+	tiled "github.com/zaklaus/go-tiled"
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/raylib-go/raymath"
+	"github.com/zaklaus/rurik/src/system"
+)
+
+const (
+	// LightPoint shines in all directions
+	LightPoint = "point"
+	// LightSpot shines in a cone along the object's facing
+	LightSpot = "spot"
+	// LightArea shines from the object's rectangle
+	LightArea = "area"
+)
+
+const (
+	// LightAnimFlicker randomly dims the light like a flame
+	LightAnimFlicker = "flicker"
+	// LightAnimPulse smoothly dims and brightens the light
+	LightAnimPulse = "pulse"
+	// LightAnimStrobe turns the light on and off
+	LightAnimStrobe = "strobe"
+	// LightAnimCycle blends the light through a list of colors
+	LightAnimCycle = "cycle"
+)
+
+// Light describes the shape and animation of the object's light
+type Light struct {
+	Type string
+
+	// ConeAngle is the width of the spot light's cone in degrees
+	ConeAngle float32
+
+	// Width and Height are the area light's size
+	Width  float32
+	Height float32
+
+	// Cookie is a texture projected by the light instead of the gradient
+	Cookie string
+
+	Animation string
+	Speed     float32
+	Amount    float32
+	Colors    []rl.Color
+	Time      float32
+	Seed      int
+
+	// Intensity and Tint are the animated light's current state
+	Intensity float32
+	Tint      rl.Color
+
+	cookie *rl.Texture2D
+}
+
+// NewLight creates a static point light
+func NewLight() *Light {
+	return &Light{
+		Type:      LightPoint,
+		ConeAngle: 45,
+		Speed:     1,
+		Amount:    0.5,
+		Intensity: 1,
+		Seed:      rand.Int() % 1000,
+	}
+}
+
+// getLightFromProperty reads the light's shape and animation from Tiled:
+// "lightType" is point, spot or area, "coneAngle" sets the spot light's width, "cookie" is a texture in gfx/,
+// "lightAnim" is flicker, pulse, strobe or cycle with "lightSpeed", "lightAmount" and ";"-separated "lightColors"
+func getLightFromProperty(o *tiled.Object) *Light {
+	l := NewLight()
+
+	if v := o.Properties.GetString("lightType"); v != "" {
+		l.Type = v
+	}
+
+	if l.Type != LightPoint && l.Type != LightSpot && l.Type != LightArea {
+		log.Printf("Light type '%s' is unknown!\n", l.Type)
+		l.Type = LightPoint
+	}
+
+	if o.Properties.GetString("coneAngle") != "" {
+		l.ConeAngle = GetFloatFromProperty(o, "coneAngle")
+	}
+
+	l.Width = float32(o.Width)
+	l.Height = float32(o.Height)
+	l.Cookie = o.Properties.GetString("cookie")
+	l.Animation = o.Properties.GetString("lightAnim")
+
+	if o.Properties.GetString("lightSpeed") != "" {
+		l.Speed = GetFloatFromProperty(o, "lightSpeed")
+	}
+
+	if o.Properties.GetString("lightAmount") != "" {
+		l.Amount = GetFloatFromProperty(o, "lightAmount")
+	}
+
+	for _, v := range splitNameList(o.Properties.GetString("lightColors")) {
+		color, err := GetColorFromHex(v)
+
+		if err != nil {
+			log.Printf("Light color '%s' is invalid!\n", v)
+			continue
+		}
+
+		l.Colors = append(l.Colors, Vec3ToColor(color))
+	}
+
+	return l
+}
+
+// Update advances the light's animation
+func (l *Light) Update(o *Object, dt float32) {
+	l.Time += dt
+	l.Intensity = 1
+	l.Tint = o.Color
+	t := l.Time * l.Speed
+
+	switch l.Animation {
+	case LightAnimFlicker:
+		l.Intensity = 1 - l.Amount*(0.5+0.5*shakeNoise(t*8, l.Seed))
+	case LightAnimPulse:
+		l.Intensity = 1 - l.Amount*(0.5+0.5*float32(math.Sin(float64(t)*math.Pi*2)))
+	case LightAnimStrobe:
+		if math.Mod(float64(t), 1) >= 0.5 {
+			l.Intensity = 1 - l.Amount
+		}
+	case LightAnimCycle:
+		if len(l.Colors) == 0 {
+			break
+		}
+
+		pos := math.Mod(float64(t), float64(len(l.Colors)))
+		a := l.Colors[int(pos)]
+		b := l.Colors[(int(pos)+1)%len(l.Colors)]
+		l.Tint = Vec3ToColor(LerpColor(ColorToVec3(a), ColorToVec3(b), pos-math.Floor(pos)))
+	}
+}
+
+// getColor returns the animated light color, the intensity scales its alpha for the additive blending
+func (l *Light) getColor(o *Object) rl.Color {
+	color := l.Tint
+
+	if l.Animation != LightAnimCycle || len(l.Colors) == 0 {
+		color = o.Color
+	}
+
+	color.A = uint8(float32(color.A) * float32(math.Max(0, math.Min(1, float64(l.Intensity)))))
+	return color
+}
+
+// getDirection returns the spot light's heading in radians, objects without facing use their rotation
+func (l *Light) getDirection(o *Object) float64 {
+	if o.Facing.X == 0 && o.Facing.Y == 0 {
+		return float64(o.Rotation) * math.Pi / 180
+	}
+
+	return math.Atan2(float64(o.Facing.Y), float64(o.Facing.X))
+}
+
+func (l *Light) getCookie() *rl.Texture2D {
+	if l.cookie == nil && l.Cookie != "" {
+		l.cookie = system.GetTexture("gfx/" + l.Cookie + ".png")
+	}
+
+	return l.cookie
+}
+
+// getLight returns the object's light, it is read from the Tiled properties on the first use
+func (o *Object) getLight() *Light {
+	if o.Light != nil {
+		return o.Light
+	}
+
+	if o.Meta != nil {
+		o.Light = getLightFromProperty(o.Meta)
+	} else {
+		o.Light = NewLight()
+	}
+
+	o.Light.Tint = o.Color
+	return o.Light
+}
+
+// drawLight draws the object's light into the multiplicative lightmap
+func drawLight(o *Object) {
+	l := o.getLight()
+	color := l.getColor(o)
+
+	if l.Type == LightArea {
+		drawAreaLight(o, l, color)
+		return
+	}
+
+	if tex := l.getCookie(); tex != nil {
+		drawLightCookie(o, l, tex, color)
+		return
+	}
+
+	if l.Type == LightSpot {
+		span := float64(l.ConeAngle) * math.Pi / 180
+		drawShadowedLight(o, color, l.getDirection(o)-span/2, span)
+		return
+	}
+
+	drawShadowedLight(o, color, 0, math.Pi*2)
+}
+
+// drawLightCookie projects the texture over the light's reach, rotated along with the light
+func drawLightCookie(o *Object, l *Light, tex *rl.Texture2D, color rl.Color) {
+	pos := rl.Vector2{X: o.Position.X + o.Offset.X, Y: o.Position.Y + o.Offset.Y}
+	size := o.Attenuation * 2
+
+	rl.DrawTexturePro(
+		*tex,
+		rl.NewRectangle(0, 0, float32(tex.Width), float32(tex.Height)),
+		rl.NewRectangle(pos.X, pos.Y, size, size),
+		rl.Vector2{X: o.Attenuation, Y: o.Attenuation},
+		float32(l.getDirection(o)*180/math.Pi),
+		color,
+	)
+}
+
+// drawAreaLight fills the rectangle and fades the light out around it over the attenuation distance
+func drawAreaLight(o *Object, l *Light, color rl.Color) {
+	x, y := o.Position.X+o.Offset.X, o.Position.Y+o.Offset.Y
+	w, h, a := l.Width, l.Height, o.Attenuation
+	b := rl.Blank
+
+	rl.DrawRectangleRec(rl.NewRectangle(x, y, w, h), color)
+
+	// vertex colors go top-left, bottom-left, bottom-right, top-right
+	rl.DrawRectangleGradientEx(rl.NewRectangle(x, y-a, w, a), b, color, color, b)
+	rl.DrawRectangleGradientEx(rl.NewRectangle(x, y+h, w, a), color, b, b, color)
+	rl.DrawRectangleGradientEx(rl.NewRectangle(x-a, y, a, h), b, b, color, color)
+	rl.DrawRectangleGradientEx(rl.NewRectangle(x+w, y, a, h), color, color, b, b)
+
+	rl.DrawRectangleGradientEx(rl.NewRectangle(x-a, y-a, a, a), b, b, color, b)
+	rl.DrawRectangleGradientEx(rl.NewRectangle(x+w, y-a, a, a), b, color, b, b)
+	rl.DrawRectangleGradientEx(rl.NewRectangle(x-a, y+h, a, a), b, b, b, color)
+	rl.DrawRectangleGradientEx(rl.NewRectangle(x+w, y+h, a, a), color, b, b, b)
+}
+
+// getLightBounds returns the area lit by the light
+func getLightBounds(o *Object, radius float32) rl.RectangleInt32 {
+	pos := rl.Vector2{X: o.Position.X + o.Offset.X, Y: o.Position.Y + o.Offset.Y}
+	var w, h float32
+
+	if o.Light != nil && o.Light.Type == LightArea {
+		w, h = o.Light.Width, o.Light.Height
+		pos = raymath.Vector2Add(pos, rl.Vector2{X: w / 2, Y: h / 2})
+	}
+
+	return rl.RectangleInt32{
+		X:      int32(pos.X - w/2 - radius),
+		Y:      int32(pos.Y - h/2 - radius),
+		Width:  int32(w+radius*2) + 1,
+		Height: int32(h+radius*2) + 1,
+	}
+}
+
+// drawLightDebug outlines the light's shape in the editor
+func drawLightDebug(o *Object) {
+	pos := rl.Vector2{X: o.Position.X + o.Offset.X, Y: o.Position.Y + o.Offset.Y}
+	l := o.getLight()
+
+	switch l.Type {
+	case LightArea:
+		rl.DrawRectangleLines(int32(pos.X), int32(pos.Y), int32(l.Width), int32(l.Height), o.Color)
+		b := getLightBounds(o, o.Attenuation)
+		rl.DrawRectangleLines(b.X, b.Y, b.Width, b.Height, rl.Fade(o.Color, 0.5))
+	case LightSpot:
+		dir := l.getDirection(o)
+		span := float64(l.ConeAngle) * math.Pi / 180
+
+		for _, a := range []float64{dir - span/2, dir + span/2} {
+			end := rl.Vector2{
+				X: pos.X + float32(math.Cos(a))*o.Attenuation,
+				Y: pos.Y + float32(math.Sin(a))*o.Attenuation,
+			}
+
+			rl.DrawLineV(pos, end, o.Color)
+		}
+	default:
+		rl.DrawCircleLines(int32(pos.X), int32(pos.Y), o.Attenuation, o.Color)
+	}
+}
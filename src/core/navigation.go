@@ -0,0 +1,719 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"container/heap"
+	"math"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/raylib-go/raymath"
+)
+
+var (
+	// NavCellSize is the size of a navigation cell, 0 uses the map's tile size
+	NavCellSize int32
+
+	// NavObstacleMask lists the collision layers blocking the navigation grid
+	NavObstacleMask = LayerBit(CollisionSolid) | LayerBit(CollisionRigid) | LayerBit(CollisionSlope)
+
+	// NavJumpPointSearch speeds up the search on open areas by skipping symmetric paths
+	NavJumpPointSearch = false
+
+	// NavSmoothPaths removes waypoints which can be skipped in a straight line
+	NavSmoothPaths = true
+
+	// NavDebugVisible draws the navigation grid and recent paths in debug mode
+	NavDebugVisible = false
+
+	// navDebugPathCount is the amount of recent paths kept for the debug overlay
+	navDebugPathCount = 8
+)
+
+const (
+	navDiagonalCost = math.Sqrt2
+)
+
+// NavGrid is a walkability grid used for path finding
+type NavGrid struct {
+	CellSize int32
+	Width    int32
+	Height   int32
+
+	static     []bool
+	dynamic    []int32
+	obstacles  map[*Object]navCellRange
+	debugPaths [][]rl.Vector2
+}
+
+// NavCell is a position on the navigation grid
+type NavCell struct {
+	X, Y int32
+}
+
+type navCellRange struct {
+	min, max NavCell
+}
+
+type navNode struct {
+	cell   NavCell
+	g, f   float64
+	parent int32
+	index  int
+	closed bool
+}
+
+type navOpenList []*navNode
+
+func (l navOpenList) Len() int           { return len(l) }
+func (l navOpenList) Less(i, j int) bool { return l[i].f < l[j].f }
+func (l navOpenList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+	l[i].index = i
+	l[j].index = j
+}
+
+func (l *navOpenList) Push(x interface{}) {
+	n := x.(*navNode)
+	n.index = len(*l)
+	*l = append(*l, n)
+}
+
+func (l *navOpenList) Pop() interface{} {
+	old := *l
+	n := old[len(old)-1]
+	*l = old[:len(old)-1]
+	n.index = -1
+	return n
+}
+
+// BuildNavGrid creates the navigation grid covering the area and marks cells blocked by static collision
+func (w *World) BuildNavGrid(width, height, cellSize int32) *NavGrid {
+	navProfiler.StartInvocation()
+	defer navProfiler.StopInvocation()
+
+	if cellSize <= 0 {
+		cellSize = 16
+	}
+
+	g := &NavGrid{
+		CellSize:  cellSize,
+		Width:     (width + cellSize - 1) / cellSize,
+		Height:    (height + cellSize - 1) / cellSize,
+		obstacles: make(map[*Object]navCellRange),
+	}
+
+	g.static = make([]bool, g.Width*g.Height)
+	g.dynamic = make([]int32, g.Width*g.Height)
+
+	for _, o := range w.Objects {
+		if o.Body == nil && isNavObstacle(o) {
+			g.markStatic(o)
+		}
+	}
+
+	w.nav = g
+	w.updateNavObstacles()
+
+	return g
+}
+
+// GetNavGrid returns the navigation grid of the world
+func (w *World) GetNavGrid() *NavGrid {
+	return w.nav
+}
+
+// FindPath returns waypoints leading from one point to another, the last waypoint is the target itself
+func (w *World) FindPath(from, to rl.Vector2) ([]rl.Vector2, bool) {
+	if w.nav == nil {
+		return nil, false
+	}
+
+	navProfiler.StartInvocation()
+	defer navProfiler.StopInvocation()
+
+	return w.nav.FindPath(from, to)
+}
+
+// FindPath returns waypoints leading from one point to another, the last waypoint is the target itself
+func (g *NavGrid) FindPath(from, to rl.Vector2) ([]rl.Vector2, bool) {
+	start := g.CellAt(from)
+	goal := g.CellAt(to)
+
+	if !g.inBounds(start) || !g.IsWalkable(goal) {
+		return nil, false
+	}
+
+	cells, ok := g.search(start, goal)
+
+	if !ok {
+		return nil, false
+	}
+
+	if NavSmoothPaths {
+		cells = g.smoothPath(cells)
+	}
+
+	path := make([]rl.Vector2, 0, len(cells))
+
+	for _, c := range cells[1:] {
+		path = append(path, g.CellCenter(c))
+	}
+
+	if len(path) == 0 {
+		path = append(path, to)
+	} else {
+		path[len(path)-1] = to
+	}
+
+	g.debugPaths = append(g.debugPaths, append([]rl.Vector2{from}, path...))
+
+	if len(g.debugPaths) > navDebugPathCount {
+		g.debugPaths = g.debugPaths[1:]
+	}
+
+	return path, true
+}
+
+// CellAt returns the cell containing the point
+func (g *NavGrid) CellAt(p rl.Vector2) NavCell {
+	return NavCell{
+		X: int32(math.Floor(float64(p.X) / float64(g.CellSize))),
+		Y: int32(math.Floor(float64(p.Y) / float64(g.CellSize))),
+	}
+}
+
+// CellCenter returns the world position of the cell's center
+func (g *NavGrid) CellCenter(c NavCell) rl.Vector2 {
+	return rl.Vector2{
+		X: float32(c.X*g.CellSize) + float32(g.CellSize)/2,
+		Y: float32(c.Y*g.CellSize) + float32(g.CellSize)/2,
+	}
+}
+
+// IsWalkable reports whether the cell is inside of the grid and not blocked
+func (g *NavGrid) IsWalkable(c NavCell) bool {
+	if !g.inBounds(c) {
+		return false
+	}
+
+	idx := c.Y*g.Width + c.X
+	return !g.static[idx] && g.dynamic[idx] == 0
+}
+
+// IsPointWalkable reports whether the point lies in a walkable cell
+func (g *NavGrid) IsPointWalkable(p rl.Vector2) bool {
+	return g.IsWalkable(g.CellAt(p))
+}
+
+func (g *NavGrid) inBounds(c NavCell) bool {
+	return c.X >= 0 && c.Y >= 0 && c.X < g.Width && c.Y < g.Height
+}
+
+func (g *NavGrid) walkable(x, y int32) bool {
+	return g.IsWalkable(NavCell{x, y})
+}
+
+// search runs A* over the grid, jump point search prunes the neighbours when enabled
+func (g *NavGrid) search(start, goal NavCell) ([]NavCell, bool) {
+	nodes := make(map[int32]*navNode)
+	open := &navOpenList{}
+
+	getNode := func(c NavCell) *navNode {
+		idx := c.Y*g.Width + c.X
+		n, ok := nodes[idx]
+
+		if !ok {
+			n = &navNode{cell: c, g: math.MaxFloat64, parent: -1, index: -1}
+			nodes[idx] = n
+		}
+
+		return n
+	}
+
+	startNode := getNode(start)
+	startNode.g = 0
+	startNode.f = navHeuristic(start, goal)
+	heap.Push(open, startNode)
+
+	for open.Len() > 0 {
+		n := heap.Pop(open).(*navNode)
+		n.closed = true
+
+		if n.cell == goal {
+			return g.reconstructPath(nodes, n), true
+		}
+
+		var successors []NavCell
+
+		if NavJumpPointSearch {
+			successors = g.jumpSuccessors(nodes, n, goal)
+		} else {
+			successors = g.neighbours(n.cell)
+		}
+
+		for _, s := range successors {
+			sn := getNode(s)
+
+			if sn.closed {
+				continue
+			}
+
+			cost := n.g + navHeuristic(n.cell, s)
+
+			if cost >= sn.g {
+				continue
+			}
+
+			sn.g = cost
+			sn.f = cost + navHeuristic(s, goal)
+			sn.parent = n.cell.Y*g.Width + n.cell.X
+
+			if sn.index >= 0 {
+				heap.Fix(open, sn.index)
+			} else {
+				heap.Push(open, sn)
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func (g *NavGrid) reconstructPath(nodes map[int32]*navNode, n *navNode) []NavCell {
+	cells := []NavCell{}
+
+	for n != nil {
+		cells = append([]NavCell{n.cell}, cells...)
+
+		if n.parent < 0 {
+			break
+		}
+
+		n = nodes[n.parent]
+	}
+
+	if !NavJumpPointSearch {
+		return cells
+	}
+
+	// NOTE: jump points are connected by straight or diagonal lines, fill in the cells between them
+	full := []NavCell{cells[0]}
+
+	for i := 1; i < len(cells); i++ {
+		c := full[len(full)-1]
+		dx := signInt32(cells[i].X - c.X)
+		dy := signInt32(cells[i].Y - c.Y)
+
+		for c != cells[i] {
+			c = NavCell{c.X + dx, c.Y + dy}
+
+			if c.X == cells[i].X {
+				dx = 0
+			}
+
+			if c.Y == cells[i].Y {
+				dy = 0
+			}
+
+			full = append(full, c)
+		}
+	}
+
+	return full
+}
+
+// neighbours lists walkable cells around the cell, diagonal moves can't cut corners
+func (g *NavGrid) neighbours(c NavCell) []NavCell {
+	out := make([]NavCell, 0, 8)
+
+	for dy := int32(-1); dy <= 1; dy++ {
+		for dx := int32(-1); dx <= 1; dx++ {
+			if dx == 0 && dy == 0 {
+				continue
+			}
+
+			if !g.walkable(c.X+dx, c.Y+dy) {
+				continue
+			}
+
+			if dx != 0 && dy != 0 && (!g.walkable(c.X+dx, c.Y) || !g.walkable(c.X, c.Y+dy)) {
+				continue
+			}
+
+			out = append(out, NavCell{c.X + dx, c.Y + dy})
+		}
+	}
+
+	return out
+}
+
+func (g *NavGrid) jumpSuccessors(nodes map[int32]*navNode, n *navNode, goal NavCell) []NavCell {
+	out := []NavCell{}
+
+	for _, nb := range g.prunedNeighbours(nodes, n) {
+		if jp, ok := g.jump(nb, n.cell, goal); ok {
+			out = append(out, jp)
+		}
+	}
+
+	return out
+}
+
+// prunedNeighbours keeps only the natural and forced neighbours in the direction of travel
+func (g *NavGrid) prunedNeighbours(nodes map[int32]*navNode, n *navNode) []NavCell {
+	if n.parent < 0 {
+		return g.neighbours(n.cell)
+	}
+
+	p := nodes[n.parent].cell
+	x, y := n.cell.X, n.cell.Y
+	dx := signInt32(x - p.X)
+	dy := signInt32(y - p.Y)
+	out := []NavCell{}
+
+	push := func(cx, cy int32) {
+		out = append(out, NavCell{cx, cy})
+	}
+
+	if dx != 0 && dy != 0 {
+		if g.walkable(x, y+dy) {
+			push(x, y+dy)
+		}
+
+		if g.walkable(x+dx, y) {
+			push(x+dx, y)
+		}
+
+		if g.walkable(x, y+dy) && g.walkable(x+dx, y) && g.walkable(x+dx, y+dy) {
+			push(x+dx, y+dy)
+		}
+	} else if dx != 0 {
+		next := g.walkable(x+dx, y)
+		top := g.walkable(x, y+1)
+		bottom := g.walkable(x, y-1)
+
+		if next {
+			push(x+dx, y)
+
+			if top && g.walkable(x+dx, y+1) {
+				push(x+dx, y+1)
+			}
+
+			if bottom && g.walkable(x+dx, y-1) {
+				push(x+dx, y-1)
+			}
+		}
+
+		if top {
+			push(x, y+1)
+		}
+
+		if bottom {
+			push(x, y-1)
+		}
+	} else {
+		next := g.walkable(x, y+dy)
+		right := g.walkable(x+1, y)
+		left := g.walkable(x-1, y)
+
+		if next {
+			push(x, y+dy)
+
+			if right && g.walkable(x+1, y+dy) {
+				push(x+1, y+dy)
+			}
+
+			if left && g.walkable(x-1, y+dy) {
+				push(x-1, y+dy)
+			}
+		}
+
+		if right {
+			push(x+1, y)
+		}
+
+		if left {
+			push(x-1, y)
+		}
+	}
+
+	return out
+}
+
+// jump moves from the parent in a straight line until it finds the goal, a forced neighbour or an obstacle
+func (g *NavGrid) jump(c, parent, goal NavCell) (NavCell, bool) {
+	dx := c.X - parent.X
+	dy := c.Y - parent.Y
+
+	for {
+		x, y := c.X, c.Y
+
+		if !g.walkable(x, y) {
+			return NavCell{}, false
+		}
+
+		if c == goal {
+			return c, true
+		}
+
+		if dx != 0 && dy != 0 {
+			if _, ok := g.jump(NavCell{x + dx, y}, c, goal); ok {
+				return c, true
+			}
+
+			if _, ok := g.jump(NavCell{x, y + dy}, c, goal); ok {
+				return c, true
+			}
+
+			// NOTE: diagonal moves need both sides open
+			if !g.walkable(x+dx, y) || !g.walkable(x, y+dy) {
+				return NavCell{}, false
+			}
+		} else if dx != 0 {
+			if (g.walkable(x, y-1) && !g.walkable(x-dx, y-1)) || (g.walkable(x, y+1) && !g.walkable(x-dx, y+1)) {
+				return c, true
+			}
+		} else if dy != 0 {
+			if (g.walkable(x-1, y) && !g.walkable(x-1, y-dy)) || (g.walkable(x+1, y) && !g.walkable(x+1, y-dy)) {
+				return c, true
+			}
+		}
+
+		c = NavCell{x + dx, y + dy}
+	}
+}
+
+// smoothPath removes waypoints visible from an earlier waypoint
+func (g *NavGrid) smoothPath(cells []NavCell) []NavCell {
+	if len(cells) < 3 {
+		return cells
+	}
+
+	out := []NavCell{cells[0]}
+	anchor := 0
+
+	for i := 2; i < len(cells); i++ {
+		if !g.lineOfSight(cells[anchor], cells[i]) {
+			anchor = i - 1
+			out = append(out, cells[anchor])
+		}
+	}
+
+	return append(out, cells[len(cells)-1])
+}
+
+// lineOfSight walks all cells the line between the cell centers passes through
+func (g *NavGrid) lineOfSight(a, b NavCell) bool {
+	dx := b.X - a.X
+	dy := b.Y - a.Y
+	nx := absInt32(dx)
+	ny := absInt32(dy)
+	sx := signInt32(dx)
+	sy := signInt32(dy)
+	c := a
+
+	for ix, iy := int32(0), int32(0); ix < nx || iy < ny; {
+		// NOTE: compare (ix+0.5)/nx with (iy+0.5)/ny to pick the next crossed cell border
+		side := (1+2*ix)*ny - (1+2*iy)*nx
+
+		if side == 0 {
+			// NOTE: passing exactly through a corner, both touched cells have to be open
+			if !g.walkable(c.X+sx, c.Y) || !g.walkable(c.X, c.Y+sy) {
+				return false
+			}
+
+			c.X += sx
+			c.Y += sy
+			ix++
+			iy++
+		} else if side < 0 {
+			c.X += sx
+			ix++
+		} else {
+			c.Y += sy
+			iy++
+		}
+
+		if !g.IsWalkable(c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// markStatic blocks all cells overlapped by the object's collision
+func (g *NavGrid) markStatic(o *Object) {
+	r := g.cellRange(getObjectBounds(o))
+
+	for y := r.min.Y; y <= r.max.Y; y++ {
+		for x := r.min.X; x <= r.max.X; x++ {
+			if g.cellOverlapsObject(NavCell{x, y}, o) {
+				g.static[y*g.Width+x] = true
+			}
+		}
+	}
+}
+
+func (g *NavGrid) cellOverlapsObject(c NavCell, o *Object) bool {
+	cell := &Object{
+		Position: rl.Vector2{X: float32(c.X * g.CellSize), Y: float32(c.Y * g.CellSize)},
+		Shape:    NewRectangleShape(0, 0, float32(g.CellSize), float32(g.CellSize)),
+	}
+
+	shape := getWorldShape(cell, rl.Vector2{})
+
+	if o.PolyLines == nil {
+		_, _, ok := testShapes(getWorldShape(o, rl.Vector2{}), shape)
+		return ok
+	}
+
+	for _, pl := range o.PolyLines {
+		pts := *pl.Points
+
+		for idx := 0; idx < len(pts)-1; idx++ {
+			p0 := rl.Vector2{X: o.Position.X + float32(pts[idx].X), Y: o.Position.Y + float32(pts[idx].Y)}
+			p1 := rl.Vector2{X: o.Position.X + float32(pts[idx+1].X), Y: o.Position.Y + float32(pts[idx+1].Y)}
+
+			if _, _, ok := raycastShape(shape, p0, raymath.Vector2Subtract(p1, p0)); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// cellRange returns the cells covered by the rectangle, clamped to the grid
+func (g *NavGrid) cellRange(rec rl.RectangleInt32) navCellRange {
+	r := navCellRange{
+		min: NavCell{floorDiv(rec.X, g.CellSize), floorDiv(rec.Y, g.CellSize)},
+		max: NavCell{floorDiv(rec.X+rec.Width-1, g.CellSize), floorDiv(rec.Y+rec.Height-1, g.CellSize)},
+	}
+
+	r.min.X = maxInt32(r.min.X, 0)
+	r.min.Y = maxInt32(r.min.Y, 0)
+	r.max.X = minInt32(r.max.X, g.Width-1)
+	r.max.Y = minInt32(r.max.Y, g.Height-1)
+
+	return r
+}
+
+func (g *NavGrid) addDynamic(r navCellRange, amount int32) {
+	for y := r.min.Y; y <= r.max.Y; y++ {
+		for x := r.min.X; x <= r.max.X; x++ {
+			g.dynamic[y*g.Width+x] += amount
+		}
+	}
+}
+
+// updateNavObstacles moves the cells blocked by rigid bodies along with them
+func (w *World) updateNavObstacles() {
+	g := w.nav
+
+	if g == nil {
+		return
+	}
+
+	seen := make(map[*Object]bool)
+
+	for _, o := range w.Objects {
+		if o.Body == nil || !isNavObstacle(o) {
+			continue
+		}
+
+		seen[o] = true
+		r := g.cellRange(o.GetAABB(o))
+		old, ok := g.obstacles[o]
+
+		if ok && old == r {
+			continue
+		}
+
+		if ok {
+			g.addDynamic(old, -1)
+		}
+
+		g.addDynamic(r, 1)
+		g.obstacles[o] = r
+	}
+
+	for o, r := range g.obstacles {
+		if !seen[o] {
+			g.addDynamic(r, -1)
+			delete(g.obstacles, o)
+		}
+	}
+}
+
+func isNavObstacle(o *Object) bool {
+	return o.IsCollidable && o.GetCollisionLayers()&NavObstacleMask != 0
+}
+
+// drawNavDebug draws blocked cells within the view, the grid lines and recent paths
+func (g *NavGrid) drawNavDebug() {
+	view := g.cellRange(GetFrustumRectangle())
+	size := g.CellSize
+
+	for y := view.min.Y; y <= view.max.Y; y++ {
+		for x := view.min.X; x <= view.max.X; x++ {
+			idx := y*g.Width + x
+			rec := rl.RectangleInt32{X: x * size, Y: y * size, Width: size, Height: size}
+
+			if g.static[idx] {
+				rl.DrawRectangle(rec.X, rec.Y, rec.Width, rec.Height, rl.Fade(rl.Red, 0.3))
+			} else if g.dynamic[idx] > 0 {
+				rl.DrawRectangle(rec.X, rec.Y, rec.Width, rec.Height, rl.Fade(rl.Orange, 0.3))
+			}
+
+			rl.DrawRectangleLines(rec.X, rec.Y, rec.Width, rec.Height, rl.Fade(rl.White, 0.1))
+		}
+	}
+
+	for _, path := range g.debugPaths {
+		for i := 0; i < len(path)-1; i++ {
+			rl.DrawLineV(path[i], path[i+1], rl.Green)
+			rl.DrawCircleV(path[i+1], 2, rl.Lime)
+		}
+	}
+}
+
+func signInt32(x int32) int32 {
+	if x > 0 {
+		return 1
+	} else if x < 0 {
+		return -1
+	}
+
+	return 0
+}
+
+func absInt32(x int32) int32 {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
+
+func navHeuristic(a, b NavCell) float64 {
+	dx := math.Abs(float64(a.X - b.X))
+	dy := math.Abs(float64(a.Y - b.Y))
+
+	return math.Max(dx, dy) + (navDiagonalCost-1)*math.Min(dx, dy)
+}
@@ -0,0 +1,258 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/rurik/src/system"
+)
+
+const (
+	// MaxNormalLights is the number of lights in view shading the normal-mapped pixels
+	MaxNormalLights = 16
+)
+
+var (
+	// NormalMappingEnabled toggles the deferred lighting of normal-mapped sprites and tiles
+	NormalMappingEnabled = true
+
+	// NormalLightHeight is the height of lights above the scene in pixels, lower lights give stronger relief
+	NormalLightHeight float32 = 24
+
+	// NormalSpecular and NormalShininess control the specular highlights of normal-mapped pixels
+	NormalSpecular  float32 = 0.4
+	NormalShininess float32 = 16
+
+	normalTexture      system.RenderTarget
+	normalShadeTexture system.RenderTarget
+	normalProgram      system.Program
+)
+
+// getNormalTexture returns the texture drawn into the normal buffer,
+// textures without a normal map are drawn black, so the lightmap is used for them instead
+func getNormalTexture(tex, normals *rl.Texture2D) (*rl.Texture2D, rl.Color) {
+	if normals != nil {
+		return normals, rl.White
+	}
+
+	return tex, rl.Black
+}
+
+// applyNormalLighting shades the normal-mapped pixels by the lights in view,
+// the diffuse term modulates the multiplicative lightmap and the specular one goes into the additive layer
+func applyNormalLighting() {
+	if !NormalMappingEnabled || !hasNormalMaps() {
+		return
+	}
+
+	if normalTexture.ID == 0 || WindowWasResized {
+		if normalTexture.ID != 0 {
+			rl.UnloadRenderTexture(normalTexture)
+			rl.UnloadRenderTexture(normalShadeTexture)
+		}
+
+		normalTexture = system.CreateRenderTarget(system.ScreenWidth, system.ScreenHeight)
+		normalShadeTexture = system.CreateRenderTarget(system.ScreenWidth, system.ScreenHeight)
+	}
+
+	if normalProgram.Shader.ID == 0 {
+		normalProgram = system.NewProgramFromCode("", normalProgramSrcCode)
+	}
+
+	populateNormalBuffer()
+	setNormalLights()
+
+	normalProgram.SetShaderValuei("mode", []int32{0}, 1)
+	normalProgram.RenderToTexture(normalTexture, normalShadeTexture)
+	drawRenderTarget(normalShadeTexture, multiplicativeLightTexture, false, rl.BlendMultiplied)
+
+	normalProgram.SetShaderValuei("mode", []int32{1}, 1)
+	normalProgram.RenderToTexture(normalTexture, normalShadeTexture)
+	drawRenderTarget(normalShadeTexture, additiveLightTexture, false, rl.BlendAdditive)
+}
+
+// hasNormalMaps checks whether any tileset or drawn object of the current map has a normal map
+func hasNormalMaps() bool {
+	if CurrentMap.hasTileNormalMaps() {
+		return true
+	}
+
+	for _, o := range drawObjects {
+		if o.NormalMap != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasTileNormalMaps checks whether any of the map's tilesets has a normal map
+func (m *Map) hasTileNormalMaps() bool {
+	for _, v := range m.tilesets {
+		if v != nil && v.Normals != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// populateNormalBuffer draws the normal maps in the same order the scene is drawn
+func populateNormalBuffer() {
+	rl.BeginTextureMode(normalTexture)
+	{
+		rl.ClearBackground(rl.Black)
+		rl.BeginMode2D(RenderCamera)
+		{
+			CurrentMap.drawTilemap(false, true)
+
+			beginSpriteBatch()
+
+			for _, o := range drawObjects {
+				o.drawNormalComponents()
+			}
+
+			endSpriteBatch()
+
+			CurrentMap.drawTilemap(true, true)
+		}
+		rl.EndMode2D()
+	}
+	rl.EndTextureMode()
+}
+
+// setNormalLights passes the lights in view to the shader in screen space
+func setNormalLights() {
+	isNight := IsNight()
+	count := 0
+	zoom := RenderCamera.Zoom
+
+	for _, o := range CurrentMap.World.Objects {
+		if count >= MaxNormalLights {
+			break
+		}
+
+		if !o.HasLight || !o.Visible || (o.NightOnly && !isNight) || !isLightWithinFrustum(o, o.Attenuation) {
+			continue
+		}
+
+		l := o.getLight()
+		color := l.getColor(o)
+		bounds := getLightBounds(o, o.Attenuation)
+		center := rl.Vector2{
+			X: float32(bounds.X) + float32(bounds.Width)/2,
+			Y: float32(bounds.Y) + float32(bounds.Height)/2,
+		}
+		radius := float32(bounds.Width) / 2
+
+		if bounds.Height > bounds.Width {
+			radius = float32(bounds.Height) / 2
+		}
+
+		normalProgram.SetShaderValue(fmt.Sprintf("lights[%d]", count), []float32{
+			(center.X-RenderCamera.Target.X)*zoom + RenderCamera.Offset.X,
+			(center.Y-RenderCamera.Target.Y)*zoom + RenderCamera.Offset.Y,
+			radius * zoom,
+			float32(color.A) / 255,
+		}, 4)
+
+		normalProgram.SetShaderValue(fmt.Sprintf("lightColors[%d]", count), []float32{
+			float32(color.R) / 255,
+			float32(color.G) / 255,
+			float32(color.B) / 255,
+		}, 3)
+
+		count++
+	}
+
+	normalProgram.SetShaderValuei("numLights", []int32{int32(count)}, 1)
+	normalProgram.SetShaderValue("lightHeight", []float32{NormalLightHeight * zoom}, 1)
+	normalProgram.SetShaderValue("specular", []float32{NormalSpecular}, 1)
+	normalProgram.SetShaderValue("shininess", []float32{NormalShininess}, 1)
+}
+
+/* Built-in shaders */
+
+const normalProgramSrcCode = `
+#version 330
+
+#define MAX_LIGHTS 16
+
+// Input vertex attributes (from vertex shader)
+in vec2 fragTexCoord;
+in vec4 fragColor;
+
+// Input uniform values
+uniform sampler2D texture0;
+uniform vec4 colDiffuse;
+uniform vec2 size = vec2(640, 480);
+uniform int mode; // 0 = diffuse, 1 = specular
+uniform int numLights;
+uniform vec4 lights[MAX_LIGHTS]; // screen position, radius and intensity
+uniform vec3 lightColors[MAX_LIGHTS];
+uniform float lightHeight;
+uniform float specular;
+uniform float shininess;
+
+// Output fragment color
+out vec4 finalColor;
+
+void main()
+{
+    vec3 texel = texture(texture0, fragTexCoord).rgb;
+
+    // black pixels have no normals, the lightmap stays as it is
+    if (texel.b < 0.1) {
+        finalColor = mode == 0 ? vec4(1.0) : vec4(0.0, 0.0, 0.0, 1.0);
+        return;
+    }
+
+    vec3 n = normalize(texel * 2.0 - 1.0);
+    vec2 screen = vec2(fragTexCoord.x, 1.0 - fragTexCoord.y) * size;
+
+    float shade = 0.0;
+    float weight = 0.0;
+    vec3 spec = vec3(0.0);
+
+    for (int i = 0; i < numLights; ++i) {
+        vec2 d = lights[i].xy - screen;
+        float falloff = clamp(1.0 - length(d) / lights[i].z, 0.0, 1.0) * lights[i].w;
+
+        if (falloff <= 0.0) {
+            continue;
+        }
+
+        // screen space goes down, normal maps point up
+        vec3 l = normalize(vec3(d.x, -d.y, lightHeight));
+        vec3 h = normalize(l + vec3(0.0, 0.0, 1.0));
+
+        // relative to a flat surface, so flat normals look the same as the lightmap
+        shade += falloff * min(max(dot(n, l), 0.0) / l.z, 1.0);
+        weight += falloff;
+        spec += lightColors[i] * falloff * pow(max(dot(n, h), 0.0), shininess) * specular;
+    }
+
+    if (mode == 0) {
+        float s = weight > 0.0 ? shade / weight : 1.0;
+        finalColor = vec4(vec3(mix(1.0, s, clamp(weight, 0.0, 1.0))), 1.0);
+    } else {
+        finalColor = vec4(spec, 1.0);
+    }
+}
+`
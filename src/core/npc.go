@@ -0,0 +1,380 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"encoding/gob"
+	"fmt"
+	"log"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/raylib-go/raymath"
+	"github.com/zaklaus/rurik/src/system"
+)
+
+var (
+	// NPCRepathInterval is the time in seconds between path updates of moving NPCs
+	NPCRepathInterval float32 = 0.5
+)
+
+type npc struct {
+	Perception    Perception
+	BrainName     string
+	CurrentState  string
+	StateTime     float32
+	MoveSpeed     float32
+	PatrolIndex   int
+	PatrolName    string
+	TargetName    string
+	WanderHeading float32
+
+	brain      *Brain
+	target     *Object
+	navPath    []rl.Vector2
+	repathTime float32
+	ownPatrol  []rl.Vector2
+}
+
+type npcData struct {
+	BrainName     string
+	CurrentState  string
+	StateTime     float32
+	PatrolIndex   int
+	TargetName    string
+	WanderHeading float32
+}
+
+// newNPCComponent character driven by a brain
+func newNPCComponent(o *Object, c *Component) {
+	o.IsCollidable = true
+	o.MoveSpeed = GetFloatFromProperty(o.Meta, "speed")
+	o.BrainName = o.Meta.Properties.GetString("brain")
+	o.TargetName = o.Meta.Properties.GetString("target")
+	o.PatrolName = o.Meta.Properties.GetString("patrol")
+	o.Perception = Perception{
+		ViewDistance:  GetFloatFromProperty(o.Meta, "view"),
+		ViewAngle:     GetFloatFromProperty(o.Meta, "fov"),
+		HearingRadius: GetFloatFromProperty(o.Meta, "hearing"),
+	}
+
+	if o.CollisionType == CollisionNone {
+		o.CollisionType = CollisionRigid
+	}
+
+	if o.MoveSpeed == 0 {
+		o.MoveSpeed = 40
+	}
+
+	if o.Perception.ViewDistance == 0 {
+		o.Perception.ViewDistance = 96
+	}
+
+	if o.Perception.ViewAngle == 0 {
+		o.Perception.ViewAngle = 90
+	}
+
+	if o.Facing.X == 0 && o.Facing.Y == 0 {
+		o.Facing = rl.NewVector2(1, 0)
+	}
+
+	c.Finish = func(o *Object) {
+		if o.FileName != "" {
+			aseData := system.GetAnimData("gfx/" + o.FileName + ".json")
+			o.Ase = &aseData
+			o.Texture = system.GetTexture("gfx/" + o.FileName + ".png")
+			o.NormalMap = system.GetNormalMap("gfx/" + o.FileName + ".png")
+			o.Size = []int32{o.Ase.FrameWidth, o.Ase.FrameHeight}
+		} else {
+			o.Size = []int32{16, 16}
+		}
+
+		// NOTE: own polylines are anchored at the spawn point, the NPC moves away from it
+		o.ownPatrol = GetPolyLinePoints(o)
+
+		if o.BrainName != "" {
+			o.SetBrain(o.BrainName)
+		}
+	}
+
+	o.GetAABB = GetSpriteAABB
+	c.Update = updateNPC
+	c.Draw = drawNPC
+	c.Batched = true
+
+	c.DrawNormals = func(o *Object) {
+		if o.Ase != nil {
+			tex, tint := getNormalTexture(o.Texture, o.NormalMap)
+			DrawSprite(*tex, GetSpriteRectangle(o), GetSpriteOrigin(o), rl.Vector2{}, 0, tint)
+		}
+	}
+
+	c.Serialize = func(o *Object, enc *gob.Encoder) {
+		enc.Encode(&npcData{
+			BrainName:     o.BrainName,
+			CurrentState:  o.CurrentState,
+			StateTime:     o.StateTime,
+			PatrolIndex:   o.PatrolIndex,
+			TargetName:    o.TargetName,
+			WanderHeading: o.WanderHeading,
+		})
+	}
+
+	c.Deserialize = func(o *Object, dec *gob.Decoder) {
+		var dat npcData
+		dec.Decode(&dat)
+
+		if dat.BrainName != "" && dat.BrainName != o.BrainName {
+			o.SetBrain(dat.BrainName)
+		}
+
+		if o.brain != nil && o.brain.States[dat.CurrentState] != nil {
+			o.CurrentState = dat.CurrentState
+		}
+
+		o.StateTime = dat.StateTime
+		o.PatrolIndex = dat.PatrolIndex
+		o.TargetName = dat.TargetName
+		o.WanderHeading = dat.WanderHeading
+		o.target = nil
+		o.navPath = nil
+	}
+}
+
+// SetBrain replaces the NPC's brain and enters its initial state
+func (o *Object) SetBrain(name string) {
+	o.BrainName = name
+	o.brain = GetBrain(name)
+	o.CurrentState = ""
+
+	if o.brain != nil {
+		o.SetBrainState(o.brain.Initial)
+	}
+}
+
+// SetBrainState switches the NPC's state, firing the exit and enter events
+func (o *Object) SetBrainState(name string) {
+	if o.brain == nil {
+		return
+	}
+
+	next, ok := o.brain.States[name]
+
+	if !ok {
+		log.Printf("NPC '%s' has no brain state '%s'!\n", o.Name, name)
+		return
+	}
+
+	if prev := o.getBrainState(); prev != nil && prev.Exit != "" {
+		FireEvent(prev.Exit, o.Name)
+	}
+
+	o.CurrentState = name
+	o.StateTime = 0
+	o.navPath = nil
+	o.repathTime = 0
+
+	if next.Enter != "" {
+		FireEvent(next.Enter, o.Name)
+	}
+}
+
+func (o *Object) getBrainState() *BrainState {
+	if o.brain == nil {
+		return nil
+	}
+
+	return o.brain.States[o.CurrentState]
+}
+
+func updateNPC(o *Object, dt float32) {
+	if o.Ase != nil {
+		o.Ase.Update(dt)
+	}
+
+	s := o.getBrainState()
+
+	if s == nil {
+		return
+	}
+
+	for _, t := range s.Transitions {
+		if t.evaluate(o) {
+			o.SetBrainState(t.To)
+			s = o.getBrainState()
+			break
+		}
+	}
+
+	o.StateTime += dt
+
+	behaviour, ok := behaviours[s.Behaviour]
+
+	if !ok {
+		if s.Behaviour != "" {
+			log.Printf("NPC '%s' uses unknown behaviour '%s'!\n", o.Name, s.Behaviour)
+			s.Behaviour = ""
+		}
+
+		behaviour = behaviours["idle"]
+	}
+
+	velocity := behaviour(o, s, dt)
+	o.MoveWithCollision(velocity, dt)
+
+	if velocity.X != 0 || velocity.Y != 0 {
+		o.Facing = velocity
+		raymath.Vector2Normalize(&o.Facing)
+	}
+
+	if o.Ase != nil {
+		animateNPC(o, velocity)
+	}
+}
+
+// animateNPC plays the walk or stand animation in the facing direction
+func animateNPC(o *Object, velocity rl.Vector2) {
+	tag := "Stand"
+
+	if velocity.X != 0 || velocity.Y != 0 {
+		tag = "Walk"
+	}
+
+	if o.Facing.Y > 0.5 {
+		tag += "N"
+	} else if o.Facing.Y < -0.5 {
+		tag += "S"
+	}
+
+	if o.Facing.X > 0.5 {
+		tag += "E"
+	} else if o.Facing.X < -0.5 {
+		tag += "W"
+	}
+
+	PlayAnim(o, tag)
+}
+
+func drawNPC(o *Object) {
+	if o.Ase != nil {
+		DrawSprite(*o.Texture, GetSpriteRectangle(o), GetSpriteOrigin(o), rl.Vector2{}, 0, rl.White)
+	} else {
+		FlushSpriteBatch()
+		rl.DrawCircleV(o.Position, float32(o.Size[0])/2, rl.Orange)
+	}
+
+	if !DebugMode || !o.DebugVisible {
+		return
+	}
+
+	FlushSpriteBatch()
+
+	color := rl.Yellow
+
+	if o.Perception.CanSee(o, o.getNPCTarget("")) {
+		color = rl.Red
+	}
+
+	o.Perception.DrawPerception(o, color)
+
+	for i := 0; i < len(o.navPath); i++ {
+		from := o.Position
+
+		if i > 0 {
+			from = o.navPath[i-1]
+		}
+
+		rl.DrawLineV(from, o.navPath[i], rl.Lime)
+	}
+
+	c := o.GetAABB(o)
+	DrawTextCentered(fmt.Sprintf("%s (%s)", o.Name, o.CurrentState), c.X+c.Width/2, c.Y+c.Height+2, 1, rl.White)
+}
+
+// getNPCTarget resolves the object by name, empty name uses the NPC's own target
+func (o *Object) getNPCTarget(name string) *Object {
+	if name == "" {
+		name = o.TargetName
+	}
+
+	if name == "" {
+		return nil
+	}
+
+	if o.target != nil && o.target.Name == name {
+		return o.target
+	}
+
+	if o.world == nil {
+		return nil
+	}
+
+	o.target, _ = o.world.FindObject(name)
+	return o.target
+}
+
+func (o *Object) getNPCSpeed(s *BrainState) float32 {
+	if s.Speed != 0 {
+		return s.Speed
+	}
+
+	return o.MoveSpeed
+}
+
+// getPatrolPoints uses the polylines of the named object, or the NPC's own ones
+func (o *Object) getPatrolPoints(name string) []rl.Vector2 {
+	if name == "" {
+		name = o.PatrolName
+	}
+
+	if name == "" || o.world == nil {
+		return o.ownPatrol
+	}
+
+	path, _ := o.world.FindObject(name)
+
+	if path == nil {
+		return nil
+	}
+
+	return GetPolyLinePoints(path)
+}
+
+// steerTowards moves directly to visible targets and uses the navigation grid otherwise
+func (o *Object) steerTowards(t *Object, speed, slowRadius, dt float32) rl.Vector2 {
+	if t == nil {
+		return rl.Vector2{}
+	}
+
+	if o.world == nil || o.world.nav == nil || hasLineOfSight(o, t) {
+		o.navPath = nil
+
+		if slowRadius > 0 {
+			return SteerArrive(o, t.Position, speed, slowRadius)
+		}
+
+		return SteerSeek(o, t.Position, speed)
+	}
+
+	o.repathTime -= dt
+
+	if o.repathTime <= 0 {
+		o.repathTime = NPCRepathInterval
+		o.navPath, _ = o.world.FindPath(o.Position, t.Position)
+	}
+
+	return SteerPath(o, &o.navPath, speed, slowRadius)
+}
@@ -19,7 +19,6 @@ package core
 import (
 	"encoding/gob"
 	"fmt"
-	"reflect"
 
 	goaseprite "github.com/zaklaus/GoAseprite"
 	tiled "github.com/zaklaus/go-tiled"
@@ -47,11 +46,14 @@ type Object struct {
 	ProxyName        string
 	FileName         string
 	Texture          *rl.Texture2D
+	NormalMap        *rl.Texture2D
 	Ase              *goaseprite.File
 	LastTrigger      float32
 	AutoStart        bool
 	IsCollidable     bool
 	CollisionType    uint32
+	CollisionLayers  uint32
+	CollisionMask    uint32
 	Started          bool
 	WasExecuted      bool
 	CanRepeat        bool
@@ -64,15 +66,25 @@ type Object struct {
 	Radius           float32
 	HasLight         bool
 	HasSpecularLight bool
+	NightOnly        bool
+	CastShadows      bool
 	IsOverlay        bool
 	Offset           rl.Vector2
 	LocalTileset     *tilesetData
 	PolyLines        []*tiled.PolyLine
+	Shape            *CollisionShape
+	Body             *RigidBody
+	Emitter          *Emitter
+	Light            *Light
+	Components       []*Component
 	UserData         ObjectUserData
 
 	// Internal fields
 	WasUpdated bool
 	world      *World
+	spatial    spatialEntry
+	drawFrame  int
+	drawIndex  int
 
 	// Callbacks
 	Init                 func(o *Object)
@@ -98,6 +110,7 @@ type Object struct {
 	anim
 	area
 	tile
+	npc
 }
 
 // ObjectUserData describes custom data used by game's classes
@@ -107,24 +120,29 @@ type ObjectUserData interface {
 }
 
 func initObjectTypes() {
-	objTypes = map[string]string{
-		"col":     "Collision",
-		"cam":     "Camera",
-		"target":  "Target",
-		"wait":    "Wait",
-		"script":  "Script",
-		"anim":    "Anim",
-		"area":    "Area",
-		"tile":    "Tile",
-		"notnull": "NotNull",
-	}
+	initDefaultComponents()
+
+	RegisterPreset("col", "collider")
+	RegisterPreset("cam", "camera")
+	RegisterPreset("target", "marker")
+	RegisterPreset("wait", "timer")
+	RegisterPreset("script", "script")
+	RegisterPreset("anim", "sprite", "animator")
+	RegisterPreset("area", "trigger")
+	RegisterPreset("tile", "tile")
+	RegisterPreset("notnull", "marker")
+	RegisterPreset("crate", "sprite", "body", "collider")
+	RegisterPreset("light", "light")
+	RegisterPreset("npc", "npc")
+	RegisterPreset("emitter", "emitter")
 }
 
 // RegisterClass adds a new object type
 func RegisterClass(class string, ctor func(o *Object)) error {
 	_, ok := objCtors[class]
+	_, isPreset := presets[class]
 
-	if ok {
+	if ok || isPreset {
 		return fmt.Errorf("can't register already existing class '%s'", class)
 	}
 
@@ -155,29 +173,22 @@ func BuildObject(w *World, o *tiled.Object, savegameData *defaultObjectData) (*O
 		className = savegameData.Type
 	}
 
-	class, ok := objTypes[className]
-
-	if !ok {
+	if _, ok := presets[className]; ok {
+		if err := inst.ApplyPreset(className); err != nil {
+			return nil, err
+		}
+	} else {
 		// custom type check
 		ctor, ctorOk := objCtors[className]
 
-		if !ctorOk && className != "" {
+		if !ctorOk {
 			return nil, fmt.Errorf("class '%s' is undefined", className)
 		}
 
 		ctor(inst)
-		return inst, nil
-	}
-
-	methodName := fmt.Sprintf("New%s", class)
-
-	method := reflect.ValueOf(inst).MethodByName(methodName)
-
-	if !method.IsValid() {
-		return nil, fmt.Errorf("internal error")
 	}
 
-	method.Call([]reflect.Value{})
+	inst.addPropertyComponents()
 
 	return inst, nil
 }
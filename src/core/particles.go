@@ -0,0 +1,614 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"encoding/gob"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"strconv"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/raylib-go/raymath"
+	"github.com/zaklaus/rurik/src/system"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// EmitterContinuous emits particles at a constant rate
+	EmitterContinuous = "continuous"
+
+	// EmitterBurst emits all particles at once
+	EmitterBurst = "burst"
+)
+
+var (
+	// DefaultMaxParticles is the pool size of emitters which don't specify it
+	DefaultMaxParticles = 256
+
+	particleDefs = make(map[string]*EmitterDef)
+)
+
+// EmitterDef describes the emitted particles, loaded from "particles/<name>.yaml" of the map or the game
+type EmitterDef struct {
+	Name string
+
+	// Emission
+	Mode         string  `yaml:"mode"`
+	Rate         float32 `yaml:"rate"`
+	Count        int     `yaml:"count"`
+	Duration     float32 `yaml:"duration"`
+	MaxParticles int     `yaml:"maxParticles"`
+
+	// Spawning
+	Lifetime         float32        `yaml:"lifetime"`
+	LifetimeVariance float32        `yaml:"lifetimeVariance"`
+	Speed            float32        `yaml:"speed"`
+	SpeedVariance    float32        `yaml:"speedVariance"`
+	Angle            float32        `yaml:"angle"`
+	Spread           float32        `yaml:"spread"`
+	SpawnRadius      float32        `yaml:"spawnRadius"`
+	Gravity          ParticleVector `yaml:"gravity"`
+	Drag             float32        `yaml:"drag"`
+
+	// Curves evaluated over the particle's lifetime
+	Color    []ParticleColorKey `yaml:"color"`
+	Size     ParticleCurve      `yaml:"size"`
+	Velocity ParticleCurve      `yaml:"velocity"`
+
+	// Rendering
+	Blend     string  `yaml:"blend"`
+	Texture   string  `yaml:"texture"`
+	Light     bool    `yaml:"light"`
+	LightSize float32 `yaml:"lightSize"`
+
+	// Collision with world solids
+	Collide        bool    `yaml:"collide"`
+	CollisionMask  string  `yaml:"collisionMask"`
+	Bounce         float32 `yaml:"bounce"`
+	DieOnCollision bool    `yaml:"dieOnCollision"`
+
+	texture *rl.Texture2D
+	mask    uint32
+}
+
+// ParticleVector is a 2D vector used by emitter definitions
+type ParticleVector struct {
+	X float32 `yaml:"x"`
+	Y float32 `yaml:"y"`
+}
+
+// ParticleCurveKey is a value at the normalized particle's age
+type ParticleCurveKey struct {
+	Time  float32 `yaml:"time"`
+	Value float32 `yaml:"value"`
+}
+
+// ParticleCurve interpolates values over the particle's lifetime
+type ParticleCurve []ParticleCurveKey
+
+// ParticleColorKey is a color in the "#AARRGGBB" format at the normalized particle's age
+type ParticleColorKey struct {
+	Time  float32 `yaml:"time"`
+	Color string  `yaml:"color"`
+
+	color rl.Vector3
+	alpha float32
+}
+
+// Emitter spawns and simulates particles of a single object, particles are kept in a fixed-size pool
+type Emitter struct {
+	Def      *EmitterDef
+	Emitting bool
+	Time     float32
+
+	accumulator float32
+	particles   []particle
+	bounds      rl.RectangleInt32
+}
+
+type particle struct {
+	Position rl.Vector2
+	Velocity rl.Vector2
+	Life     float32
+	MaxLife  float32
+}
+
+// GetEmitterDef loads the emitter definition or returns the cached one
+func GetEmitterDef(name string) *EmitterDef {
+	fileName := fmt.Sprintf("map/%s/particles/%s.yaml", system.MapName, name)
+	asset := system.FindAsset(fileName)
+
+	if asset == nil {
+		fileName = fmt.Sprintf("particles/%s.yaml", name)
+		asset = system.FindAsset(fileName)
+	}
+
+	if asset == nil {
+		log.Printf("Emitter '%s' could not be found!\n", name)
+		return nil
+	}
+
+	if def, ok := particleDefs[fileName]; ok {
+		return def
+	}
+
+	def := &EmitterDef{
+		Name:         name,
+		Mode:         EmitterContinuous,
+		MaxParticles: DefaultMaxParticles,
+		Lifetime:     1,
+		LightSize:    4,
+	}
+
+	if err := yaml.Unmarshal(asset.Data, def); err != nil {
+		log.Printf("Emitter '%s' could not be loaded: %s\n", name, err.Error())
+		return nil
+	}
+
+	for i := range def.Color {
+		k := &def.Color[i]
+		color, err := GetColorFromHex(k.Color)
+
+		if err != nil {
+			log.Printf("Emitter '%s' has an invalid color '%s'!\n", name, k.Color)
+			color = ColorToVec3(rl.White)
+		}
+
+		k.color = color
+		k.alpha = 1
+
+		if len(k.Color) == 9 {
+			if a, err := strconv.ParseUint(k.Color[1:3], 16, 8); err == nil {
+				k.alpha = float32(a) / 255
+			}
+		}
+	}
+
+	if def.Texture != "" {
+		def.texture = system.GetTexture("gfx/" + def.Texture + ".png")
+	}
+
+	def.mask = RetrieveCollisionMask(splitCollisionTypeNames(def.CollisionMask))
+	particleDefs[fileName] = def
+	return def
+}
+
+// FlushEmitterDefs drops the cached emitter definitions, so they get reloaded on the next use
+func FlushEmitterDefs() {
+	particleDefs = make(map[string]*EmitterDef)
+}
+
+// NewEmitter creates an emitter with a pool sized by the definition
+func NewEmitter(def *EmitterDef) *Emitter {
+	return &Emitter{
+		Def:       def,
+		particles: make([]particle, 0, def.MaxParticles),
+	}
+}
+
+// Start begins the emission, burst emitters emit all of their particles at once
+func (e *Emitter) Start(o *Object) {
+	e.Time = 0
+	e.accumulator = 0
+
+	if e.Def.Mode == EmitterBurst {
+		e.Burst(o, e.Def.Count)
+		return
+	}
+
+	e.Emitting = true
+}
+
+// Stop ends the emission, alive particles are removed when clear is set
+func (e *Emitter) Stop(clear bool) {
+	e.Emitting = false
+
+	if clear {
+		e.particles = e.particles[:0]
+	}
+}
+
+// Burst emits the given number of particles, limited by the pool size
+func (e *Emitter) Burst(o *Object, count int) {
+	for i := 0; i < count; i++ {
+		e.spawn(o)
+	}
+}
+
+// IsAlive checks whether the emitter is emitting or has any alive particles
+func (e *Emitter) IsAlive() bool {
+	return e.Emitting || len(e.particles) > 0
+}
+
+// Update emits new particles and simulates the alive ones
+func (e *Emitter) Update(o *Object, dt float32) {
+	if e.Emitting {
+		e.Time += dt
+		e.accumulator += e.Def.Rate * dt
+
+		for e.accumulator >= 1 {
+			e.accumulator--
+			e.spawn(o)
+		}
+
+		if e.Def.Duration > 0 && e.Time >= e.Def.Duration {
+			e.Emitting = false
+		}
+	}
+
+	if len(e.particles) == 0 {
+		e.bounds = rl.RectangleInt32{X: int32(o.Position.X), Y: int32(o.Position.Y)}
+		return
+	}
+
+	var solids []*Object
+
+	if e.Def.Collide && o.world != nil {
+		area := expandRectangle(getObjectBounds(o), 16+int32(e.Def.SpawnRadius))
+
+		for _, v := range o.world.QueryObjects(area) {
+			if v != o && matchesQueryMask(v, e.Def.mask) {
+				solids = append(solids, v)
+			}
+		}
+	}
+
+	minX, minY := float32(math.MaxFloat32), float32(math.MaxFloat32)
+	maxX, maxY := -minX, -minY
+
+	for i := 0; i < len(e.particles); {
+		p := &e.particles[i]
+		p.Life -= dt
+
+		if p.Life <= 0 {
+			e.kill(i)
+			continue
+		}
+
+		age := 1 - p.Life/p.MaxLife
+		p.Velocity.X += e.Def.Gravity.X * dt
+		p.Velocity.Y += e.Def.Gravity.Y * dt
+
+		if e.Def.Drag > 0 {
+			raymath.Vector2Scale(&p.Velocity, float32(math.Max(0, float64(1-e.Def.Drag*dt))))
+		}
+
+		delta := p.Velocity
+		raymath.Vector2Scale(&delta, e.Def.Velocity.Evaluate(age, 1)*dt)
+
+		if len(solids) > 0 && e.collide(p, solids, delta) {
+			if e.Def.DieOnCollision {
+				e.kill(i)
+				continue
+			}
+		} else {
+			p.Position = raymath.Vector2Add(p.Position, delta)
+		}
+
+		minX = float32(math.Min(float64(minX), float64(p.Position.X)))
+		minY = float32(math.Min(float64(minY), float64(p.Position.Y)))
+		maxX = float32(math.Max(float64(maxX), float64(p.Position.X)))
+		maxY = float32(math.Max(float64(maxY), float64(p.Position.Y)))
+		i++
+	}
+
+	if len(e.particles) > 0 {
+		e.bounds = rl.RectangleInt32{
+			X:      int32(minX),
+			Y:      int32(minY),
+			Width:  int32(maxX-minX) + 1,
+			Height: int32(maxY-minY) + 1,
+		}
+	}
+}
+
+// Draw renders the particles, additive emitters are blended onto the scene
+func (e *Emitter) Draw() {
+	if len(e.particles) == 0 {
+		return
+	}
+
+	blend := rl.BlendAlpha
+
+	if e.Def.Blend == "additive" {
+		blend = rl.BlendAdditive
+	}
+
+	// NOTE: particles without a texture are drawn as circles outside of the sprite batch
+	if e.Def.texture == nil {
+		FlushSpriteBatch()
+		rl.BeginBlendMode(blend)
+		defer rl.EndBlendMode()
+	}
+
+	for i := range e.particles {
+		p := &e.particles[i]
+		age := 1 - p.Life/p.MaxLife
+		size := e.Def.Size.Evaluate(age, 2)
+		color := e.Def.getColor(age)
+
+		if size <= 0 || color.A == 0 {
+			continue
+		}
+
+		if e.Def.texture != nil {
+			tex := *e.Def.texture
+			DrawSpriteBlended(
+				tex,
+				rl.NewRectangle(0, 0, float32(tex.Width), float32(tex.Height)),
+				rl.NewRectangle(p.Position.X-size/2, p.Position.Y-size/2, size, size),
+				rl.Vector2{},
+				0,
+				color,
+				blend,
+			)
+			continue
+		}
+
+		rl.DrawCircleV(p.Position, size/2, color)
+	}
+}
+
+// drawLight contributes the particles' glow to the additive lighting pass
+func (e *Emitter) drawLight() {
+	if !e.Def.Light {
+		return
+	}
+
+	for i := range e.particles {
+		p := &e.particles[i]
+		age := 1 - p.Life/p.MaxLife
+		radius := e.Def.Size.Evaluate(age, 2) * e.Def.LightSize / 2
+		in := e.Def.getColor(age)
+		in.A = uint8(float32(in.A) * 0.35)
+		out := in
+		out.A = 0
+
+		rl.DrawCircleGradient(int32(p.Position.X), int32(p.Position.Y), radius, in, out)
+	}
+}
+
+func (e *Emitter) spawn(o *Object) {
+	if len(e.particles) >= cap(e.particles) {
+		return
+	}
+
+	def := e.Def
+	angle := float64(def.Angle+(rand.Float32()*2-1)*def.Spread/2) * math.Pi / 180
+	speed := def.Speed + (rand.Float32()*2-1)*def.SpeedVariance
+	life := def.Lifetime + (rand.Float32()*2-1)*def.LifetimeVariance
+
+	if life <= 0 {
+		life = 0.01
+	}
+
+	pos := raymath.Vector2Add(o.Position, o.Offset)
+
+	if def.SpawnRadius > 0 {
+		a := rand.Float64() * math.Pi * 2
+		r := def.SpawnRadius * float32(math.Sqrt(rand.Float64()))
+		pos.X += float32(math.Cos(a)) * r
+		pos.Y += float32(math.Sin(a)) * r
+	}
+
+	e.particles = append(e.particles, particle{
+		Position: pos,
+		Velocity: rl.NewVector2(float32(math.Cos(angle))*speed, float32(math.Sin(angle))*speed),
+		Life:     life,
+		MaxLife:  life,
+	})
+}
+
+// kill returns the particle to the pool by swapping it with the last alive one
+func (e *Emitter) kill(i int) {
+	last := len(e.particles) - 1
+	e.particles[i] = e.particles[last]
+	e.particles = e.particles[:last]
+}
+
+// collide moves the particle up to the closest solid and reflects its velocity
+func (e *Emitter) collide(p *particle, solids []*Object, delta rl.Vector2) bool {
+	var closest float32 = 1
+	var normal rl.Vector2
+	hit := false
+
+	for _, c := range solids {
+		var t float32
+		var n rl.Vector2
+		var ok bool
+
+		if c.PolyLines != nil && c.CollisionType == CollisionSlope {
+			t, n, ok = raycastPolyLines(c, p.Position, delta)
+		} else {
+			t, n, ok = raycastShape(getWorldShape(c, rl.Vector2{}), p.Position, delta)
+		}
+
+		if ok && t <= closest {
+			closest = t
+			normal = n
+			hit = true
+		}
+	}
+
+	if !hit {
+		return false
+	}
+
+	// NOTE: stop right at the surface, lifted off by half a pixel along its normal
+	raymath.Vector2Scale(&delta, closest)
+	lift := normal
+	raymath.Vector2Scale(&lift, 0.5)
+	p.Position = raymath.Vector2Add(p.Position, raymath.Vector2Add(delta, lift))
+
+	reflect := normal
+	raymath.Vector2Scale(&reflect, 2*raymath.Vector2DotProduct(p.Velocity, normal))
+	p.Velocity = raymath.Vector2Subtract(p.Velocity, reflect)
+	raymath.Vector2Scale(&p.Velocity, e.Def.Bounce)
+
+	return true
+}
+
+// Evaluate interpolates the curve at the given time, an empty curve returns the fallback value
+func (c ParticleCurve) Evaluate(t, fallback float32) float32 {
+	if len(c) == 0 {
+		return fallback
+	}
+
+	if t <= c[0].Time {
+		return c[0].Value
+	}
+
+	for i := 1; i < len(c); i++ {
+		if t <= c[i].Time {
+			a, b := c[i-1], c[i]
+			return ScalarLerp(a.Value, b.Value, (t-a.Time)/(b.Time-a.Time))
+		}
+	}
+
+	return c[len(c)-1].Value
+}
+
+func (def *EmitterDef) getColor(t float32) rl.Color {
+	keys := def.Color
+
+	if len(keys) == 0 {
+		return rl.White
+	}
+
+	a, b := keys[len(keys)-1], keys[len(keys)-1]
+	var progress float32
+
+	for i := range keys {
+		if t > keys[i].Time {
+			continue
+		}
+
+		a, b = keys[i], keys[i]
+
+		if i > 0 {
+			a = keys[i-1]
+			progress = (t - a.Time) / (b.Time - a.Time)
+		}
+
+		break
+	}
+
+	color := Vec3ToColor(LerpColor(a.color, b.color, float64(progress)))
+	alpha := ScalarLerp(a.alpha, b.alpha, progress)
+	color.A = uint8(math.Max(0, math.Min(1, float64(alpha))) * 255)
+
+	return color
+}
+
+// drawParticles renders the emitters within the camera's view
+func drawParticles(w *World) {
+	candidates := w.Objects
+
+	if cullingEnabled {
+		candidates = w.QueryObjects(GetFrustumRectangle())
+	}
+
+	for _, o := range candidates {
+		if o.Emitter != nil && o.Visible {
+			o.Emitter.Draw()
+		}
+	}
+}
+
+// drawParticleLights renders the glow of the emitters into the lighting pass
+func drawParticleLights(w *World) {
+	for _, o := range w.QueryObjects(GetFrustumRectangle()) {
+		if o.Emitter != nil && o.Visible {
+			o.Emitter.drawLight()
+		}
+	}
+}
+
+// newEmitterComponent spawns particles described in the "file" property.
+// The emitter starts on its own with the "autostart" property, or when triggered
+func newEmitterComponent(o *Object, c *Component) {
+	c.Finish = func(o *Object) {
+		if o.FileName == "" {
+			log.Printf("Emitter '%s' has no file specified!\n", o.Name)
+			return
+		}
+
+		def := GetEmitterDef(o.FileName)
+
+		if def == nil {
+			return
+		}
+
+		wasEmitting := o.Emitter != nil && o.Emitter.Emitting
+		o.Emitter = NewEmitter(def)
+
+		if o.AutoStart || wasEmitting {
+			o.Emitter.Start(o)
+		}
+	}
+
+	c.Update = func(o *Object, dt float32) {
+		if o.Emitter != nil {
+			o.Emitter.Update(o, dt)
+		}
+	}
+
+	c.Trigger = func(o, inst *Object) {
+		if o.Emitter != nil {
+			o.Emitter.Start(o)
+		}
+	}
+
+	c.Serialize = func(o *Object, enc *gob.Encoder) {
+		enc.Encode(o.Emitter != nil && o.Emitter.Emitting)
+	}
+
+	c.Deserialize = func(o *Object, dec *gob.Decoder) {
+		var emitting bool
+		dec.Decode(&emitting)
+
+		if o.Emitter != nil {
+			o.Emitter.Emitting = emitting
+		}
+	}
+
+	o.GetAABB = func(o *Object) rl.RectangleInt32 {
+		if o.Emitter == nil {
+			return rl.RectangleInt32{X: int32(o.Position.X), Y: int32(o.Position.Y)}
+		}
+
+		return o.Emitter.bounds
+	}
+}
+
+// FindEmitter returns the object of the current map with a loaded emitter
+func FindEmitter(name string) *Object {
+	if CurrentMap == nil {
+		return nil
+	}
+
+	o, _ := CurrentMap.World.FindObject(name)
+
+	if o == nil || o.Emitter == nil {
+		return nil
+	}
+
+	return o
+}
@@ -0,0 +1,128 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"math"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/raylib-go/raymath"
+)
+
+var (
+	// SightBlockingMask lists the collision layers blocking the line of sight
+	SightBlockingMask = LayerBit(CollisionSolid)
+)
+
+// Perception describes the senses of an object
+type Perception struct {
+	// ViewDistance is the maximum distance the object can see to
+	ViewDistance float32
+
+	// ViewAngle is the full angle of the view cone in degrees
+	ViewAngle float32
+
+	// HearingRadius is the distance within which moving objects are heard
+	HearingRadius float32
+}
+
+// CanSee checks whether the target is within the view cone and not hidden behind a wall
+func (p Perception) CanSee(o, target *Object) bool {
+	if target == nil || o == target {
+		return false
+	}
+
+	offset := raymath.Vector2Subtract(target.Position, o.Position)
+	dist := raymath.Vector2Length(offset)
+
+	if dist > p.ViewDistance {
+		return false
+	}
+
+	if dist > 0 && p.ViewAngle < 360 && raymath.Vector2Length(o.Facing) > 0 {
+		facing := o.Facing
+		raymath.Vector2Normalize(&facing)
+		cos := raymath.Vector2DotProduct(facing, offset) / dist
+
+		if cos < float32(math.Cos(float64(p.ViewAngle)/2*math.Pi/180)) {
+			return false
+		}
+	}
+
+	return hasLineOfSight(o, target)
+}
+
+// CanHear checks whether the target moves within the hearing radius, walls don't block sounds
+func (p Perception) CanHear(o, target *Object) bool {
+	if target == nil || o == target {
+		return false
+	}
+
+	if raymath.Vector2Distance(target.Position, o.Position) > p.HearingRadius {
+		return false
+	}
+
+	moving := target.Movement.X != 0 || target.Movement.Y != 0
+
+	if target.Body != nil {
+		moving = moving || target.Body.Velocity.X != 0 || target.Body.Velocity.Y != 0
+	}
+
+	return moving
+}
+
+// DrawPerception draws the view cone and the hearing radius of the object
+func (p Perception) DrawPerception(o *Object, color rl.Color) {
+	heading := math.Atan2(float64(o.Facing.Y), float64(o.Facing.X))
+	half := float64(p.ViewAngle) / 2 * math.Pi / 180
+	segments := 12
+
+	prev := o.Position
+
+	for i := 0; i <= segments; i++ {
+		angle := heading - half + 2*half*float64(i)/float64(segments)
+		point := rl.Vector2{
+			X: o.Position.X + float32(math.Cos(angle))*p.ViewDistance,
+			Y: o.Position.Y + float32(math.Sin(angle))*p.ViewDistance,
+		}
+
+		rl.DrawLineV(prev, point, color)
+		prev = point
+	}
+
+	rl.DrawLineV(prev, o.Position, color)
+
+	if p.HearingRadius > 0 {
+		rl.DrawCircleLines(int32(o.Position.X), int32(o.Position.Y), p.HearingRadius, rl.Fade(color, 0.5))
+	}
+}
+
+func hasLineOfSight(o, target *Object) bool {
+	if o.world == nil {
+		return true
+	}
+
+	hits := o.world.RaycastAll(o.Position, target.Position, SightBlockingMask)
+
+	for _, v := range hits {
+		if v.Object != o && v.Object != target {
+			return false
+		}
+	}
+
+	return true
+}
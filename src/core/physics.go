@@ -0,0 +1,372 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"math"
+
+	tiled "github.com/zaklaus/go-tiled"
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/raylib-go/raymath"
+	"github.com/zaklaus/resolv/resolv"
+	"github.com/zaklaus/rurik/src/system"
+)
+
+const (
+	// BodyDynamic is moved by its velocity and reacts to collisions and impulses
+	BodyDynamic uint32 = iota
+	// BodyKinematic is moved by its velocity only and pushes dynamic bodies away
+	BodyKinematic
+)
+
+var (
+	// PhysicsEnabled toggles the physics step
+	PhysicsEnabled = true
+
+	// PhysicsGravity is the acceleration applied to all dynamic bodies
+	PhysicsGravity rl.Vector2
+
+	// PhysicsIterations limits the amount of contacts solved per body each step
+	PhysicsIterations = 4
+
+	// PhysicsContactSkin is the distance within which bodies are considered touching
+	PhysicsContactSkin float32 = 1
+)
+
+// RigidBody holds the dynamics of a simulated object
+type RigidBody struct {
+	Type     uint32
+	Velocity rl.Vector2
+	Mass     float32
+
+	// Friction slows the body down over time, in units per second
+	Friction float32
+
+	// Restitution is the bounciness of the body, 0 means no bounce at all
+	Restitution float32
+
+	force    rl.Vector2
+	contacts []bodyContact
+}
+
+type bodyContact struct {
+	other *Object
+	res   resolv.Collision
+}
+
+// NewRigidBody creates a rigid body with default settings
+func NewRigidBody(bodyType uint32) *RigidBody {
+	return &RigidBody{
+		Type:     bodyType,
+		Mass:     1,
+		Friction: 4,
+	}
+}
+
+// getBodyFromProperty creates a rigid body for objects marked as dynamic or kinematic in Tiled
+func getBodyFromProperty(o *tiled.Object) *RigidBody {
+	var body *RigidBody
+
+	switch o.Properties.GetString("body") {
+	case "dynamic":
+		body = NewRigidBody(BodyDynamic)
+	case "kinematic":
+		body = NewRigidBody(BodyKinematic)
+	default:
+		return nil
+	}
+
+	if o.Properties.GetString("mass") != "" {
+		body.Mass = GetFloatFromProperty(o, "mass")
+	}
+
+	if o.Properties.GetString("friction") != "" {
+		body.Friction = GetFloatFromProperty(o, "friction")
+	}
+
+	body.Restitution = GetFloatFromProperty(o, "restitution")
+	body.Velocity = GetVec2FromProperty(o, "velocity")
+
+	return body
+}
+
+// newBodyComponent simulates the object as a rigid body, dynamic unless Tiled says otherwise
+func newBodyComponent(o *Object, c *Component) {
+	o.IsCollidable = true
+
+	if o.CollisionType == CollisionNone {
+		o.CollisionType = CollisionRigid
+	}
+
+	if o.Body == nil {
+		o.Body = NewRigidBody(BodyDynamic)
+	}
+}
+
+// inverseMass returns zero for immovable objects
+func (b *RigidBody) inverseMass() float32 {
+	if b == nil || b.Type != BodyDynamic || b.Mass <= 0 {
+		return 0
+	}
+
+	return 1 / b.Mass
+}
+
+// ApplyImpulse changes the body's velocity instantly, heavier bodies react less
+func (o *Object) ApplyImpulse(x, y float32) {
+	if o.Body == nil || o.Body.Type != BodyDynamic {
+		return
+	}
+
+	im := o.Body.inverseMass()
+	o.Body.Velocity.X += x * im
+	o.Body.Velocity.Y += y * im
+}
+
+// ApplyForce accumulates a force applied during the next physics step
+func (o *Object) ApplyForce(x, y float32) {
+	if o.Body == nil {
+		return
+	}
+
+	o.Body.force.X += x
+	o.Body.force.Y += y
+}
+
+// stepPhysics moves all bodies, kinematic bodies go first so dynamic ones can react to them
+func (w *World) stepPhysics(dt float32) {
+	if !PhysicsEnabled || dt == 0 {
+		return
+	}
+
+	physicsProfiler.StartInvocation()
+
+	for _, o := range w.Objects {
+		if o.Body != nil && o.Body.Type == BodyKinematic {
+			o.Body.force = rl.Vector2{}
+			o.SetPosition(o.Position.X+o.Body.Velocity.X*dt, o.Position.Y+o.Body.Velocity.Y*dt)
+			w.pushDynamicBodies(o)
+		}
+	}
+
+	for _, o := range w.Objects {
+		if o.Body != nil && o.Body.Type == BodyDynamic {
+			w.stepBody(o, dt)
+		}
+	}
+
+	physicsProfiler.StopInvocation()
+}
+
+func (w *World) stepBody(o *Object, dt float32) {
+	b := o.Body
+	im := b.inverseMass()
+
+	b.Velocity.X += (b.force.X*im + PhysicsGravity.X) * dt
+	b.Velocity.Y += (b.force.Y*im + PhysicsGravity.Y) * dt
+	b.force = rl.Vector2{}
+
+	damping := float32(math.Max(0, float64(1-b.Friction*dt)))
+	raymath.Vector2Scale(&b.Velocity, damping)
+
+	if math.Abs(float64(b.Velocity.X)) < 0.01 && math.Abs(float64(b.Velocity.Y)) < 0.01 {
+		b.Velocity = rl.Vector2{}
+	}
+
+	prevContacts := b.contacts
+	b.contacts = []bodyContact{}
+	delta := b.Velocity
+	raymath.Vector2Scale(&delta, dt)
+
+	for i := 0; i < PhysicsIterations; i++ {
+		res, contact, ok := checkForContact(o.GetCollisionMask(), o, delta.X, delta.Y)
+
+		if !ok {
+			o.SetPosition(o.Position.X+delta.X, o.Position.Y+delta.Y)
+			break
+		}
+
+		o.SetPosition(o.Position.X+contact.Movement.X, o.Position.Y+contact.Movement.Y)
+		b.addContact(contact.Object, res)
+		resolveBodyImpulse(o, contact.Object, contact.Normal)
+
+		// NOTE: slide along the surface with the rest of the movement
+		rest := delta
+		raymath.Vector2Scale(&rest, 1-contact.Time)
+
+		if contact.Time == 0 {
+			rest = rl.Vector2{}
+		}
+
+		slide := contact.Normal
+		raymath.Vector2Scale(&slide, float32(math.Min(float64(raymath.Vector2DotProduct(rest, contact.Normal)), 0)))
+		delta = raymath.Vector2Subtract(rest, slide)
+
+		if raymath.Vector2Length(delta) < 0.01 {
+			break
+		}
+	}
+
+	// NOTE: resting bodies don't hit anything while sweeping, so touching objects are gathered separately
+	for _, c := range w.getTouchingObjects(o) {
+		b.addContact(c, resolv.Collision{})
+	}
+
+	for _, v := range b.contacts {
+		if !hasBodyContact(prevContacts, v.other) {
+			res := v.res
+			o.HandleCollisionEnter(&res, o, v.other)
+			v.other.HandleCollisionEnter(&res, v.other, o)
+		}
+	}
+
+	for _, v := range prevContacts {
+		if !hasBodyContact(b.contacts, v.other) {
+			res := v.res
+			o.HandleCollisionLeave(&res, o, v.other)
+			v.other.HandleCollisionLeave(&res, v.other, o)
+		}
+	}
+}
+
+// getTouchingObjects returns objects within the contact skin of the body, slopes are approximated by their bounds
+func (w *World) getTouchingObjects(o *Object) []*Object {
+	mask := o.GetCollisionMask()
+	skin := PhysicsContactSkin
+	offsets := []rl.Vector2{{}, {X: skin}, {X: -skin}, {Y: skin}, {Y: -skin}}
+	touching := []*Object{}
+
+	for _, c := range w.QueryObjects(expandRectangle(getObjectBounds(o), int32(math.Ceil(float64(skin)))+1)) {
+		if c == o || !c.IsCollidable || c.CollisionType == CollisionTrigger || c.GetCollisionLayers()&mask == 0 {
+			continue
+		}
+
+		other := getWorldShape(c, rl.Vector2{})
+
+		for _, v := range offsets {
+			if _, _, ok := testShapes(getWorldShape(o, v), other); ok {
+				touching = append(touching, c)
+				break
+			}
+		}
+	}
+
+	return touching
+}
+
+// pushDynamicBodies moves dynamic bodies out of the kinematic body, they're carried along with its velocity
+func (w *World) pushDynamicBodies(o *Object) {
+	shape := getWorldShape(o, rl.Vector2{})
+	layers := o.GetCollisionLayers()
+
+	for _, c := range w.QueryObjects(expandRectangle(getObjectBounds(o), 1)) {
+		if c == o || c.Body == nil || c.Body.Type != BodyDynamic || c.GetCollisionMask()&layers == 0 {
+			continue
+		}
+
+		// NOTE: normal points from the kinematic body towards the pushed one
+		normal, depth, ok := testShapes(getWorldShape(c, rl.Vector2{}), shape)
+
+		if !ok {
+			continue
+		}
+
+		push := normal
+		raymath.Vector2Scale(&push, depth)
+		c.SetPosition(c.Position.X+push.X, c.Position.Y+push.Y)
+
+		speed := raymath.Vector2DotProduct(o.Body.Velocity, normal)
+		current := raymath.Vector2DotProduct(c.Body.Velocity, normal)
+
+		if speed > current {
+			carry := normal
+			raymath.Vector2Scale(&carry, speed-current)
+			c.Body.Velocity = raymath.Vector2Add(c.Body.Velocity, carry)
+		}
+	}
+}
+
+// resolveBodyImpulse bounces the body off the other object, normal points towards the body
+func resolveBodyImpulse(o, other *Object, normal rl.Vector2) {
+	var otherVelocity rl.Vector2
+	restitution := o.Body.Restitution
+
+	if other.Body != nil {
+		otherVelocity = other.Body.Velocity
+		restitution = float32(math.Max(float64(restitution), float64(other.Body.Restitution)))
+	}
+
+	vn := raymath.Vector2DotProduct(raymath.Vector2Subtract(o.Body.Velocity, otherVelocity), normal)
+
+	if vn >= 0 {
+		return
+	}
+
+	imA := o.Body.inverseMass()
+	imB := other.Body.inverseMass()
+	j := -(1 + restitution) * vn / (imA + imB)
+
+	impulse := normal
+	raymath.Vector2Scale(&impulse, j)
+
+	o.Body.Velocity.X += impulse.X * imA
+	o.Body.Velocity.Y += impulse.Y * imA
+
+	if imB > 0 {
+		other.Body.Velocity.X -= impulse.X * imB
+		other.Body.Velocity.Y -= impulse.Y * imB
+	}
+}
+
+// pushBody lets objects without a body push dynamic bodies around, heavier bodies move slower
+func pushBody(pusher, body *Object, normal rl.Vector2, deltaX, deltaY float32) {
+	if pusher.Body != nil || body.Body == nil || body.Body.Type != BodyDynamic {
+		return
+	}
+
+	dt := system.FrameTime * float32(TimeScale)
+
+	if dt == 0 {
+		return
+	}
+
+	// NOTE: normal points towards the pusher
+	dir := rl.Vector2{X: -normal.X, Y: -normal.Y}
+	speed := raymath.Vector2DotProduct(rl.Vector2{X: deltaX / dt, Y: deltaY / dt}, dir) / float32(math.Max(float64(body.Body.Mass), 1))
+	current := raymath.Vector2DotProduct(body.Body.Velocity, dir)
+
+	if speed > current {
+		raymath.Vector2Scale(&dir, speed-current)
+		body.Body.Velocity = raymath.Vector2Add(body.Body.Velocity, dir)
+	}
+}
+
+func (b *RigidBody) addContact(other *Object, res resolv.Collision) {
+	if !hasBodyContact(b.contacts, other) {
+		b.contacts = append(b.contacts, bodyContact{other: other, res: res})
+	}
+}
+
+func hasBodyContact(contacts []bodyContact, other *Object) bool {
+	for _, v := range contacts {
+		if v.other == other {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,373 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"log"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/rurik/src/system"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// PostFXOutputPush blends the pass over the world in the compositor
+	PostFXOutputPush = "push"
+	// PostFXOutputReplace draws the pass back into the world texture
+	PostFXOutputReplace = "replace"
+	// PostFXOutputNone keeps the pass only as an input for the following passes
+	PostFXOutputNone = "none"
+
+	// PostFXInputWorld is the game world rendered in the current frame
+	PostFXInputWorld = "world"
+)
+
+var (
+	// PostFXFile describes the post-processing stack, maps can override it with map/<name>/postfx.yaml
+	PostFXFile = "misc/postfx.yaml"
+
+	// PostFXEnabled toggles the post-processing stack of all maps
+	PostFXEnabled = true
+
+	postFXNodeIsCollapsed = true
+)
+
+// PostFXStack is an ordered list of post-processing passes
+type PostFXStack struct {
+	Passes []*PostFXPass `yaml:"passes"`
+}
+
+// PostFXPass renders its input through a shader into its own render target,
+// the result is then either pushed to the compositor, drawn back into the world or used by the later passes
+type PostFXPass struct {
+	Name string `yaml:"name"`
+
+	// Shader is a fragment shader in assets/, passes without it only copy the input
+	Shader string `yaml:"shader"`
+
+	// Input is either the world or the name of a previous pass
+	Input string `yaml:"input"`
+
+	// Scale sets the render target's size relative to the screen, Width and Height set it in pixels
+	Scale  float32 `yaml:"scale"`
+	Width  int32   `yaml:"width"`
+	Height int32   `yaml:"height"`
+
+	// Blur and Dirtify are the built-in effects applied after the shader
+	Blur    int  `yaml:"blur"`
+	Dirtify bool `yaml:"dirtify"`
+
+	Output string `yaml:"output"`
+	Blend  string `yaml:"blend"`
+
+	// Disabled passes are only used by maps listing them in the "postfx" property
+	Disabled bool `yaml:"disabled"`
+
+	Uniforms []*PostFXUniform `yaml:"uniforms"`
+
+	Enabled     bool `yaml:"-"`
+	IsCollapsed bool `yaml:"-"`
+
+	program system.Program
+	target  system.RenderTarget
+	flipped bool
+}
+
+// PostFXUniform is a float uniform of up to 4 components, Min and Max limit its editor sliders
+type PostFXUniform struct {
+	Name  string    `yaml:"name"`
+	Value []float64 `yaml:"value"`
+	Min   float64   `yaml:"min"`
+	Max   float64   `yaml:"max"`
+}
+
+// ApplyPostFX runs the current map's post-processing stack
+func ApplyPostFX() {
+	if !PostFXEnabled || CurrentMap == nil || CurrentMap.PostFX == nil {
+		return
+	}
+
+	postFXProfiler.StartInvocation()
+	CurrentMap.PostFX.Apply()
+	postFXProfiler.StopInvocation()
+}
+
+// loadPostFX loads the map's post-processing stack,
+// the map's "postfx" property lists the enabled passes separated by ";", "none" disables all of them
+func loadPostFX(cmap *Map) *PostFXStack {
+	asset := system.FindAsset(fmt.Sprintf("map/%s/postfx.yaml", cmap.Name))
+
+	if asset == nil {
+		asset = system.FindAsset(PostFXFile)
+	}
+
+	stack := &PostFXStack{}
+
+	if asset == nil {
+		return stack
+	}
+
+	if err := yaml.Unmarshal(asset.Data, stack); err != nil {
+		log.Printf("Post-processing stack could not be loaded: %s\n", err.Error())
+		return &PostFXStack{}
+	}
+
+	passes := cmap.tilemap.Properties.GetString("postfx")
+	enabled := splitNameList(passes)
+
+	for _, p := range stack.Passes {
+		p.Enabled = !p.Disabled
+		p.IsCollapsed = true
+		p.Uniforms = getValidPostFXUniforms(p)
+
+		if passes != "" {
+			p.Enabled = false
+
+			for _, v := range enabled {
+				if v == p.Name {
+					p.Enabled = true
+				}
+			}
+		}
+	}
+
+	for _, v := range enabled {
+		if v != "none" && stack.GetPass(v) == nil {
+			log.Printf("Post-processing pass '%s' could not be found!\n", v)
+		}
+	}
+
+	return stack
+}
+
+// GetPass returns the pass with the given name
+func (s *PostFXStack) GetPass(name string) *PostFXPass {
+	for _, p := range s.Passes {
+		if p.Name == name {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// Apply renders all enabled passes in order
+func (s *PostFXStack) Apply() {
+	for i, p := range s.Passes {
+		if !p.Enabled {
+			continue
+		}
+
+		source, flipped, ok := s.getInput(p, i)
+
+		if !ok {
+			continue
+		}
+
+		p.render(source, flipped)
+
+		if !p.Enabled {
+			continue
+		}
+
+		switch p.Output {
+		case PostFXOutputNone:
+		case PostFXOutputReplace:
+			drawRenderTarget(p.target, WorldTexture, !p.flipped, p.getBlendMode())
+		default:
+			PushRenderTarget(p.target, p.flipped, p.getBlendMode())
+		}
+	}
+}
+
+// getInput returns the pass's source and whether it is upside down compared to the world
+func (s *PostFXStack) getInput(p *PostFXPass, index int) (system.RenderTarget, bool, bool) {
+	if p.Input == "" || p.Input == PostFXInputWorld {
+		return WorldTexture, false, true
+	}
+
+	for _, v := range s.Passes[:index] {
+		if v.Name == p.Input {
+			return v.target, v.flipped, v.Enabled
+		}
+	}
+
+	log.Printf("Post-processing pass '%s' uses unknown input '%s'!\n", p.Name, p.Input)
+	p.Enabled = false
+	return system.RenderTarget{}, false, false
+}
+
+// render draws the source through the pass's shader and built-in effects,
+// each shader pass flips the image, so the orientation is tracked for the output
+func (p *PostFXPass) render(source system.RenderTarget, flipped bool) {
+	p.updateTarget()
+
+	if p.Shader != "" {
+		if p.program.Shader.ID == 0 {
+			if system.FindAsset(p.Shader) == nil {
+				log.Printf("Post-processing pass '%s' uses unknown shader '%s'!\n", p.Name, p.Shader)
+				p.Enabled = false
+				return
+			}
+
+			p.program = system.NewProgram("", p.Shader)
+		}
+
+		for _, u := range p.Uniforms {
+			p.program.SetShaderValue(u.Name, u.getValues(), int32(len(u.Value)))
+		}
+
+		p.program.RenderToTexture(source, p.target)
+		flipped = !flipped
+	} else {
+		system.CopyToRenderTarget(source, p.target, true)
+	}
+
+	if p.Blur > 0 {
+		BlurRenderTarget(p.target, p.Blur)
+	}
+
+	if p.Dirtify {
+		DirtifyRenderTarget(p.target)
+	}
+
+	p.flipped = flipped
+}
+
+// updateTarget creates the pass's render target and resizes it along with the window
+func (p *PostFXPass) updateTarget() {
+	if p.target.ID != 0 && !WindowWasResized {
+		return
+	}
+
+	if p.target.ID != 0 {
+		rl.UnloadRenderTexture(p.target)
+	}
+
+	width, height := p.Width, p.Height
+
+	if width == 0 || height == 0 {
+		scale := p.Scale
+
+		if scale <= 0 {
+			scale = 1
+		}
+
+		width = int32(float32(system.ScreenWidth) * scale)
+		height = int32(float32(system.ScreenHeight) * scale)
+	}
+
+	p.target = system.CreateRenderTarget(width, height)
+}
+
+// getValidPostFXUniforms drops the uniforms which can not be passed to the shader
+func getValidPostFXUniforms(p *PostFXPass) []*PostFXUniform {
+	uniforms := []*PostFXUniform{}
+
+	for _, u := range p.Uniforms {
+		if len(u.Value) < 1 || len(u.Value) > 4 {
+			log.Printf("Post-processing pass '%s' has uniform '%s' with %d components, it needs 1 to 4!\n", p.Name, u.Name, len(u.Value))
+			continue
+		}
+
+		uniforms = append(uniforms, u)
+	}
+
+	return uniforms
+}
+
+func (p *PostFXPass) getBlendMode() rl.BlendMode {
+	switch p.Blend {
+	case "additive":
+		return rl.BlendAdditive
+	case "multiplied":
+		return rl.BlendMultiplied
+	}
+
+	return rl.BlendAlpha
+}
+
+func (u *PostFXUniform) getValues() []float32 {
+	values := make([]float32, len(u.Value))
+
+	for i, v := range u.Value {
+		values[i] = float32(v)
+	}
+
+	return values
+}
+
+// unload releases the passes' shaders and render targets
+func (s *PostFXStack) unload() {
+	for _, p := range s.Passes {
+		if p.target.ID != 0 {
+			rl.UnloadRenderTexture(p.target)
+		}
+
+		if p.program.Shader.ID != 0 {
+			rl.UnloadShader(p.program.Shader)
+		}
+	}
+}
+
+// drawPostFXUI lists the map's passes with toggles and sliders bound to their uniforms
+func drawPostFXUI(mapNode *EditorElement) {
+	stack := CurrentMap.PostFX
+	postFXNode := PushEditorElement(mapNode, fmt.Sprintf("post-processing (%d passes)", len(stack.Passes)), &postFXNodeIsCollapsed)
+
+	if postFXNodeIsCollapsed {
+		return
+	}
+
+	SetUpButton(
+		PushEditorElement(postFXNode, fmt.Sprintf("Post-processing: %t", PostFXEnabled), nil),
+		func() {
+			PostFXEnabled = !PostFXEnabled
+		},
+		false,
+	)
+
+	for _, v := range stack.Passes {
+		p := v
+		passNode := PushEditorElement(postFXNode, fmt.Sprintf("%s (%s)", p.Name, p.Shader), &p.IsCollapsed)
+
+		if p.IsCollapsed {
+			continue
+		}
+
+		SetUpButton(
+			PushEditorElement(passNode, fmt.Sprintf("Enabled: %t", p.Enabled), nil),
+			func() {
+				p.Enabled = !p.Enabled
+			},
+			false,
+		)
+
+		for _, u := range p.Uniforms {
+			for i := range u.Value {
+				label := u.Name
+
+				if len(u.Value) > 1 {
+					label = fmt.Sprintf("%s.%s", u.Name, string("xyzw"[i]))
+				}
+
+				SetUpSlider(PushEditorElement(passNode, label+":", nil), &u.Value[i], u.Min, u.Max)
+			}
+		}
+	}
+}
@@ -0,0 +1,306 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strings"
+
+	tiled "github.com/zaklaus/go-tiled"
+	"github.com/zaklaus/rurik/src/system"
+	"gopkg.in/yaml.v2"
+)
+
+// MapManifest lists every asset a map depends on
+type MapManifest struct {
+	Name     string
+	Textures []string
+	Anims    []string
+	Files    []string
+
+	seen map[string]bool
+}
+
+// ClassDependencyScanner adds assets used by an object of a specific class
+type ClassDependencyScanner func(m *MapManifest, o *tiled.Object)
+
+// FileDependencyScanner adds assets referenced inside of a file
+type FileDependencyScanner func(m *MapManifest, fileName string, data []byte)
+
+var (
+	classDependencyScanners = map[string]ClassDependencyScanner{}
+
+	componentDependencyScanners = map[string]ClassDependencyScanner{
+		"sprite": func(m *MapManifest, o *tiled.Object) {
+			fileName := o.Properties.GetString("file")
+
+			if fileName == "" {
+				return
+			}
+
+			m.AddTexture("gfx/" + fileName + ".png")
+
+			if system.AssetExists("gfx/" + fileName + ".json") {
+				m.AddAnim("gfx/" + fileName + ".json")
+			}
+		},
+		"npc": func(m *MapManifest, o *tiled.Object) {
+			fileName := o.Properties.GetString("file")
+
+			if fileName != "" {
+				m.AddTexture("gfx/" + fileName + ".png")
+				m.AddAnim("gfx/" + fileName + ".json")
+			}
+		},
+		"script": func(m *MapManifest, o *tiled.Object) {
+			fileName := o.Properties.GetString("file")
+
+			if fileName == "" {
+				return
+			}
+
+			if system.AssetExists("scripts/" + fileName) {
+				m.AddFile("scripts/" + fileName)
+			} else {
+				m.AddFile(fmt.Sprintf("map/%s/scripts/%s", m.Name, fileName))
+			}
+		},
+		"emitter": func(m *MapManifest, o *tiled.Object) {
+			fileName := o.Properties.GetString("file")
+
+			if fileName == "" {
+				return
+			}
+
+			filePath := fmt.Sprintf("map/%s/particles/%s.yaml", m.Name, fileName)
+
+			if !system.AssetExists(filePath) {
+				filePath = "particles/" + fileName + ".yaml"
+			}
+
+			if !system.AssetExists(filePath) {
+				return
+			}
+
+			m.AddFile(filePath)
+			asset := system.FindAsset(filePath)
+
+			if asset == nil {
+				return
+			}
+
+			var def struct {
+				Texture string `yaml:"texture"`
+			}
+
+			if yaml.Unmarshal(asset.Data, &def) == nil && def.Texture != "" {
+				m.AddTexture("gfx/" + def.Texture + ".png")
+			}
+		},
+	}
+
+	fileDependencyScanners []FileDependencyScanner
+)
+
+// RegisterClassDependencies registers a scanner reporting assets used by a class
+func RegisterClassDependencies(class string, scanner ClassDependencyScanner) {
+	classDependencyScanners[class] = scanner
+}
+
+// RegisterComponentDependencies registers a scanner reporting assets used by a component
+func RegisterComponentDependencies(component string, scanner ClassDependencyScanner) {
+	componentDependencyScanners[component] = scanner
+}
+
+// RegisterFileDependencies registers a scanner reporting assets referenced inside of files
+func RegisterFileDependencies(scanner FileDependencyScanner) {
+	fileDependencyScanners = append(fileDependencyScanners, scanner)
+}
+
+// AddTexture adds a texture to the manifest
+func (m *MapManifest) AddTexture(fileName string) {
+	if m.mark("tex:" + fileName) {
+		m.Textures = append(m.Textures, fileName)
+
+		if normalMap := system.GetNormalMapName(fileName); system.AssetExists(normalMap) {
+			m.AddTexture(normalMap)
+		}
+	}
+}
+
+// AddAnim adds an Aseprite anim data file to the manifest
+func (m *MapManifest) AddAnim(fileName string) {
+	if m.mark("anim:" + fileName) {
+		m.Anims = append(m.Anims, fileName)
+	}
+}
+
+// AddFile adds a generic file to the manifest, the file gets scanned for further dependencies
+func (m *MapManifest) AddFile(fileName string) {
+	if !m.mark("file:" + fileName) {
+		return
+	}
+
+	m.Files = append(m.Files, fileName)
+
+	if len(fileDependencyScanners) == 0 {
+		return
+	}
+
+	asset := system.FindAsset(fileName)
+
+	if asset == nil {
+		return
+	}
+
+	for _, scanner := range fileDependencyScanners {
+		scanner(m, fileName, asset.Data)
+	}
+}
+
+func (m *MapManifest) mark(key string) bool {
+	if m.seen[key] {
+		return false
+	}
+
+	m.seen[key] = true
+	return true
+}
+
+// ScanMapDependencies walks the map and collects all assets it depends on
+func ScanMapDependencies(name string) (*MapManifest, error) {
+	m := &MapManifest{
+		Name: name,
+		seen: make(map[string]bool),
+	}
+
+	mapFileName := fmt.Sprintf("map/%s/%s.tmx", name, name)
+	mapAsset := system.FindAsset(mapFileName)
+
+	if mapAsset == nil {
+		return nil, fmt.Errorf("map '%s' does not exist", name)
+	}
+
+	tilemap, err := tiled.LoadFromReader("", bytes.NewReader(mapAsset.Data))
+
+	if err != nil {
+		return nil, fmt.Errorf("map '%s' could not be parsed: %s", name, err.Error())
+	}
+
+	m.AddFile(mapFileName)
+
+	for _, ts := range tilemap.Tilesets {
+		if ts.Source != "" {
+			m.addTileset(ts.Source)
+		}
+	}
+
+	for _, group := range tilemap.ObjectGroups {
+		for _, object := range group.Objects {
+			m.addObject(object)
+		}
+	}
+
+	// map-local scripts and texts are looked up by name at runtime
+	prefix := fmt.Sprintf("map/%s/", name)
+
+	for _, v := range system.AssetFileNames() {
+		if strings.HasPrefix(v, prefix) {
+			m.AddFile(v)
+		}
+	}
+
+	return m, nil
+}
+
+// PreloadMap loads all of the map's assets in the background.
+// The progress callback is invoked every frame until the preload finishes.
+func PreloadMap(name string, progress func(done, total int)) (*system.AssetPreload, error) {
+	m, err := ScanMapDependencies(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return system.PreloadAssets(name, m.Textures, m.Anims, m.Files, progress), nil
+}
+
+func (m *MapManifest) addTileset(source string) {
+	fileName := fmt.Sprintf("tilesets/%s", path.Base(source))
+
+	if m.seen["file:"+fileName] {
+		return
+	}
+
+	m.AddFile(fileName)
+	asset := system.FindAsset(fileName)
+
+	if asset == nil {
+		return
+	}
+
+	var ts tilesetData
+
+	if xml.Unmarshal(asset.Data, &ts) == nil && ts.ImageInfo.Source != "" {
+		m.AddTexture(fmt.Sprintf("tilesets/%s", path.Base(ts.ImageInfo.Source)))
+	}
+}
+
+func (m *MapManifest) addObject(object *tiled.Object) {
+	obj := *object
+	obj.Properties = append(tiled.Properties{}, object.Properties...)
+
+	if obj.Template != "" {
+		fileName := path.Join("templates", path.Base(obj.Template))
+		m.AddFile(fileName)
+
+		if asset := system.FindAsset(fileName); asset != nil {
+			var tpl objectTemplate
+			xml.Unmarshal(asset.Data, &tpl)
+
+			if tpl.Tileset.Source != "" {
+				m.addTileset(tpl.Tileset.Source)
+			}
+
+			for _, prop := range tpl.Object.Properties {
+				if obj.Properties.GetString(prop.Name) == "" {
+					obj.Properties = append(obj.Properties, prop)
+				}
+			}
+
+			if obj.Type == "" {
+				obj.Type = tpl.Object.Type
+			}
+		}
+	}
+
+	if scanner, ok := classDependencyScanners[obj.Type]; ok {
+		scanner(m, &obj)
+	}
+
+	components := append([]string{}, GetPresetComponents(obj.Type)...)
+	components = append(components, splitNameList(obj.Properties.GetString("components"))...)
+
+	for _, v := range components {
+		if scanner, ok := componentDependencyScanners[v]; ok {
+			scanner(m, &obj)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"math"
+	"sort"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/raylib-go/raymath"
+)
+
+var (
+	// QueriesHitStartingShapes makes casts report shapes containing the starting point,
+	// disabled by default so objects can cast from inside of their own shape
+	QueriesHitStartingShapes = false
+)
+
+// QueryHit describes an object found by a cast or an overlap query.
+// Casts report the impact point, surface normal and distance travelled,
+// overlaps report the object's center, the separating normal and penetration depth.
+type QueryHit struct {
+	Object   *Object
+	Point    rl.Vector2
+	Normal   rl.Vector2
+	Distance float32
+}
+
+// Raycast returns the closest object hit by the segment, an empty mask matches all collision layers
+func (w *World) Raycast(from, to rl.Vector2, mask uint32) (QueryHit, bool) {
+	hits := w.RaycastAll(from, to, mask)
+
+	if len(hits) == 0 {
+		return QueryHit{}, false
+	}
+
+	return hits[0], true
+}
+
+// RaycastAll returns all objects hit by the segment sorted by distance
+func (w *World) RaycastAll(from, to rl.Vector2, mask uint32) []QueryHit {
+	dir := raymath.Vector2Subtract(to, from)
+	length := raymath.Vector2Length(dir)
+	hits := []QueryHit{}
+
+	for _, c := range w.QueryObjects(segmentBounds(from, to, 0)) {
+		if !matchesQueryMask(c, mask) {
+			continue
+		}
+
+		var t float32
+		var normal rl.Vector2
+		var ok bool
+
+		if c.PolyLines != nil && c.CollisionType == CollisionSlope {
+			t, normal, ok = raycastPolyLines(c, from, dir)
+		} else {
+			t, normal, ok = raycastShape(getWorldShape(c, rl.Vector2{}), from, dir)
+		}
+
+		if !ok || (t == 0 && !QueriesHitStartingShapes) {
+			continue
+		}
+
+		hits = append(hits, QueryHit{
+			Object:   c,
+			Point:    Vector2Lerp(from, to, t),
+			Normal:   normal,
+			Distance: t * length,
+		})
+	}
+
+	sortQueryHits(hits)
+	return hits
+}
+
+// CircleCast sweeps a circle along the segment and returns the closest object it hits
+func (w *World) CircleCast(from, to rl.Vector2, radius float32, mask uint32) (QueryHit, bool) {
+	hits := w.CircleCastAll(from, to, radius, mask)
+
+	if len(hits) == 0 {
+		return QueryHit{}, false
+	}
+
+	return hits[0], true
+}
+
+// CircleCastAll sweeps a circle along the segment and returns all objects it hits sorted by distance
+func (w *World) CircleCastAll(from, to rl.Vector2, radius float32, mask uint32) []QueryHit {
+	probe := &Object{
+		Position: from,
+		Shape:    NewCircleShape(0, 0, radius),
+	}
+
+	delta := raymath.Vector2Subtract(to, from)
+	length := raymath.Vector2Length(delta)
+	hits := []QueryHit{}
+
+	for _, c := range w.QueryObjects(segmentBounds(from, to, int32(math.Ceil(float64(radius))))) {
+		if !matchesQueryMask(c, mask) {
+			continue
+		}
+
+		contact, ok := sweepShape(probe, getWorldShape(c, rl.Vector2{}), delta)
+
+		if !ok || (contact.Time == 0 && contact.Depth > 0 && !QueriesHitStartingShapes) {
+			continue
+		}
+
+		center := Vector2Lerp(from, to, contact.Time)
+		offset := contact.Normal
+		raymath.Vector2Scale(&offset, radius)
+
+		hits = append(hits, QueryHit{
+			Object:   c,
+			Point:    raymath.Vector2Subtract(center, offset),
+			Normal:   contact.Normal,
+			Distance: contact.Time * length,
+		})
+	}
+
+	sortQueryHits(hits)
+	return hits
+}
+
+// OverlapRect returns all objects overlapping the rectangle
+func (w *World) OverlapRect(rec rl.RectangleInt32, mask uint32) []QueryHit {
+	probe := &Object{
+		Position: rl.Vector2{X: float32(rec.X), Y: float32(rec.Y)},
+		Shape:    NewRectangleShape(0, 0, float32(rec.Width), float32(rec.Height)),
+	}
+
+	return w.overlapShape(probe, rec, mask)
+}
+
+// OverlapCircle returns all objects overlapping the circle
+func (w *World) OverlapCircle(center rl.Vector2, radius float32, mask uint32) []QueryHit {
+	probe := &Object{
+		Position: center,
+		Shape:    NewCircleShape(0, 0, radius),
+	}
+
+	return w.overlapShape(probe, GetShapeBounds(probe), mask)
+}
+
+func (w *World) overlapShape(probe *Object, bounds rl.RectangleInt32, mask uint32) []QueryHit {
+	shape := getWorldShape(probe, rl.Vector2{})
+	hits := []QueryHit{}
+
+	for _, c := range w.QueryObjects(expandRectangle(bounds, 1)) {
+		if !matchesQueryMask(c, mask) {
+			continue
+		}
+
+		other := getWorldShape(c, rl.Vector2{})
+		normal, depth, ok := testShapes(other, shape)
+
+		if !ok {
+			continue
+		}
+
+		hits = append(hits, QueryHit{
+			Object:   c,
+			Point:    other.center,
+			Normal:   normal,
+			Distance: depth,
+		})
+	}
+
+	return hits
+}
+
+func matchesQueryMask(o *Object, mask uint32) bool {
+	if !o.IsCollidable {
+		return false
+	}
+
+	return mask == 0 || o.GetCollisionLayers()&mask != 0
+}
+
+// raycastShape clips the ray against the shape, t is the fraction of the ray before the impact
+func raycastShape(s worldShape, from, dir rl.Vector2) (float32, rl.Vector2, bool) {
+	if s.isCircle {
+		return raycastCircle(s.center, s.radius, from, dir)
+	}
+
+	if len(s.points) < 3 {
+		return 0, rl.Vector2{}, false
+	}
+
+	var tEnter float32
+	tExit := float32(1)
+	var normal rl.Vector2
+
+	// NOTE: Cyrus-Beck clipping, the winding decides which way the edge normals face
+	winding := float32(1)
+
+	if polygonArea(s.points) < 0 {
+		winding = -1
+	}
+
+	for i := range s.points {
+		p0 := s.points[i]
+		p1 := s.points[(i+1)%len(s.points)]
+		edge := raymath.Vector2Subtract(p1, p0)
+
+		if edge.X == 0 && edge.Y == 0 {
+			continue
+		}
+
+		n := rl.Vector2{X: edge.Y * winding, Y: -edge.X * winding}
+		raymath.Vector2Normalize(&n)
+
+		num := raymath.Vector2DotProduct(n, raymath.Vector2Subtract(p0, from))
+		den := raymath.Vector2DotProduct(n, dir)
+
+		if den == 0 {
+			if num < 0 {
+				return 0, rl.Vector2{}, false
+			}
+
+			continue
+		}
+
+		t := num / den
+
+		if den < 0 {
+			if t > tEnter {
+				tEnter = t
+				normal = n
+			}
+		} else if t < tExit {
+			tExit = t
+		}
+
+		if tEnter > tExit {
+			return 0, rl.Vector2{}, false
+		}
+	}
+
+	if normal.X == 0 && normal.Y == 0 {
+		normal = getRayBackNormal(dir)
+	}
+
+	return tEnter, normal, true
+}
+
+func raycastCircle(center rl.Vector2, radius float32, from, dir rl.Vector2) (float32, rl.Vector2, bool) {
+	m := raymath.Vector2Subtract(from, center)
+	a := raymath.Vector2DotProduct(dir, dir)
+	b := raymath.Vector2DotProduct(m, dir)
+	c := raymath.Vector2DotProduct(m, m) - radius*radius
+
+	if c <= 0 {
+		return 0, getRayBackNormal(dir), true
+	}
+
+	disc := b*b - a*c
+
+	if a == 0 || disc < 0 {
+		return 0, rl.Vector2{}, false
+	}
+
+	t := (-b - float32(math.Sqrt(float64(disc)))) / a
+
+	if t < 0 || t > 1 {
+		return 0, rl.Vector2{}, false
+	}
+
+	// NOTE: the normal points from the center towards the hit, which is m + dir*t
+	normal := dir
+	raymath.Vector2Scale(&normal, t)
+	normal = raymath.Vector2Add(m, normal)
+	raymath.Vector2Normalize(&normal)
+	return t, normal, true
+}
+
+// getRayBackNormal returns the normal facing against the ray, used when the ray starts inside of a shape
+func getRayBackNormal(dir rl.Vector2) rl.Vector2 {
+	if dir.X == 0 && dir.Y == 0 {
+		return rl.Vector2{}
+	}
+
+	raymath.Vector2Normalize(&dir)
+	raymath.Vector2Negate(&dir)
+	return dir
+}
+
+// raycastPolyLines intersects the ray with slope lines, the normal faces the ray's origin
+func raycastPolyLines(o *Object, from, dir rl.Vector2) (float32, rl.Vector2, bool) {
+	best := float32(2)
+	var normal rl.Vector2
+
+	for _, pl := range o.PolyLines {
+		pts := *pl.Points
+
+		for idx := 0; idx < len(pts)-1; idx++ {
+			p0 := rl.Vector2{X: o.Position.X + float32(pts[idx].X), Y: o.Position.Y + float32(pts[idx].Y)}
+			p1 := rl.Vector2{X: o.Position.X + float32(pts[idx+1].X), Y: o.Position.Y + float32(pts[idx+1].Y)}
+			edge := raymath.Vector2Subtract(p1, p0)
+
+			den := dir.X*edge.Y - dir.Y*edge.X
+
+			if den == 0 {
+				continue
+			}
+
+			diff := raymath.Vector2Subtract(p0, from)
+			t := (diff.X*edge.Y - diff.Y*edge.X) / den
+			u := (diff.X*dir.Y - diff.Y*dir.X) / den
+
+			if t < 0 || t > 1 || u < 0 || u > 1 || t >= best {
+				continue
+			}
+
+			best = t
+			normal = rl.Vector2{X: -edge.Y, Y: edge.X}
+			raymath.Vector2Normalize(&normal)
+
+			if raymath.Vector2DotProduct(normal, dir) > 0 {
+				raymath.Vector2Negate(&normal)
+			}
+		}
+	}
+
+	return best, normal, best <= 1
+}
+
+func polygonArea(points []rl.Vector2) float32 {
+	var area float32
+
+	for i := range points {
+		p0 := points[i]
+		p1 := points[(i+1)%len(points)]
+		area += p0.X*p1.Y - p1.X*p0.Y
+	}
+
+	return area / 2
+}
+
+func segmentBounds(from, to rl.Vector2, margin int32) rl.RectangleInt32 {
+	x0 := int32(math.Floor(math.Min(float64(from.X), float64(to.X))))
+	y0 := int32(math.Floor(math.Min(float64(from.Y), float64(to.Y))))
+	x1 := int32(math.Ceil(math.Max(float64(from.X), float64(to.X))))
+	y1 := int32(math.Ceil(math.Max(float64(from.Y), float64(to.Y))))
+
+	return expandRectangle(rl.RectangleInt32{X: x0, Y: y0, Width: x1 - x0, Height: y1 - y0}, margin+1)
+}
+
+func sortQueryHits(hits []QueryHit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Distance < hits[j].Distance
+	})
+}
@@ -0,0 +1,56 @@
+package core
+
+func questInitParticleCommands(q *QuestManager) {
+	// emitstart <object>
+	q.RegisterCommand("emitstart", func(qs *Quest, qt *QuestTask, args []string) bool {
+		if len(args) != 1 {
+			return QuestCommandErrorArgCount("emitstart", qs, qt, len(args), 1)
+		}
+
+		o := FindEmitter(args[0])
+
+		if o == nil {
+			return QuestCommandErrorThing("emitstart", "emitter", qs, qt, args[0])
+		}
+
+		o.Emitter.Start(o)
+		return true
+	})
+
+	// emitstop <object> [clear]
+	q.RegisterCommand("emitstop", func(qs *Quest, qt *QuestTask, args []string) bool {
+		if len(args) < 1 {
+			return QuestCommandErrorArgCount("emitstop", qs, qt, len(args), 1)
+		}
+
+		o := FindEmitter(args[0])
+
+		if o == nil {
+			return QuestCommandErrorThing("emitstop", "emitter", qs, qt, args[0])
+		}
+
+		o.Emitter.Stop(len(args) > 1 && args[1] == "clear")
+		return true
+	})
+
+	// emitburst <object> <count>
+	q.RegisterCommand("emitburst", func(qs *Quest, qt *QuestTask, args []string) bool {
+		if len(args) != 2 {
+			return QuestCommandErrorArgCount("emitburst", qs, qt, len(args), 2)
+		}
+
+		o := FindEmitter(args[0])
+		count, countFound := qs.GetNumberOrVariable(args[1])
+
+		if o == nil {
+			return QuestCommandErrorThing("emitburst", "emitter", qs, qt, args[0])
+		}
+
+		if !countFound {
+			return QuestCommandErrorThing("emitburst", "number", qs, qt, args[1])
+		}
+
+		o.Emitter.Burst(o, int(count))
+		return true
+	})
+}
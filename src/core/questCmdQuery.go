@@ -0,0 +1,147 @@
+package core
+
+import (
+	rl "github.com/zaklaus/raylib-go/raylib"
+)
+
+func questInitQueryCommands(q *QuestManager) {
+	// raycast <dist> <point> <from> <to> [layers...]
+	q.RegisterCommand("raycast", func(qs *Quest, qt *QuestTask, args []string) bool {
+		if len(args) < 4 {
+			return QuestCommandErrorArgCount("raycast", qs, qt, len(args), 4)
+		}
+
+		from, fromFound := qs.GetVector(args[2])
+		to, toFound := qs.GetVector(args[3])
+
+		if !fromFound {
+			return QuestCommandErrorThing("raycast", "vector", qs, qt, args[2])
+		}
+
+		if !toFound {
+			return QuestCommandErrorThing("raycast", "vector", qs, qt, args[3])
+		}
+
+		if CurrentMap == nil {
+			return QuestCommandErrorThing("raycast", "map", qs, qt, "CurrentMap")
+		}
+
+		hit, ok := CurrentMap.World.Raycast(from, to, RetrieveCollisionMask(args[4:]))
+		questSetQueryHit(qs, args[0], args[1], hit, ok)
+
+		qs.Printf(qt, "raycast hit: %t", ok)
+		return true
+	})
+
+	// circlecast <dist> <point> <from> <to> <radius> [layers...]
+	q.RegisterCommand("circlecast", func(qs *Quest, qt *QuestTask, args []string) bool {
+		if len(args) < 5 {
+			return QuestCommandErrorArgCount("circlecast", qs, qt, len(args), 5)
+		}
+
+		from, fromFound := qs.GetVector(args[2])
+		to, toFound := qs.GetVector(args[3])
+		radius, radiusFound := qs.GetNumberOrVariable(args[4])
+
+		if !fromFound {
+			return QuestCommandErrorThing("circlecast", "vector", qs, qt, args[2])
+		}
+
+		if !toFound {
+			return QuestCommandErrorThing("circlecast", "vector", qs, qt, args[3])
+		}
+
+		if !radiusFound {
+			return QuestCommandErrorThing("circlecast", "number", qs, qt, args[4])
+		}
+
+		if CurrentMap == nil {
+			return QuestCommandErrorThing("circlecast", "map", qs, qt, "CurrentMap")
+		}
+
+		hit, ok := CurrentMap.World.CircleCast(from, to, float64to32(radius), RetrieveCollisionMask(args[5:]))
+		questSetQueryHit(qs, args[0], args[1], hit, ok)
+
+		qs.Printf(qt, "circlecast hit: %t", ok)
+		return true
+	})
+
+	// overlaprect <count> <pos> <width> <height> [layers...]
+	q.RegisterCommand("overlaprect", func(qs *Quest, qt *QuestTask, args []string) bool {
+		if len(args) < 4 {
+			return QuestCommandErrorArgCount("overlaprect", qs, qt, len(args), 4)
+		}
+
+		pos, posFound := qs.GetVector(args[1])
+		width, widthFound := qs.GetNumberOrVariable(args[2])
+		height, heightFound := qs.GetNumberOrVariable(args[3])
+
+		if !posFound {
+			return QuestCommandErrorThing("overlaprect", "vector", qs, qt, args[1])
+		}
+
+		if !widthFound {
+			return QuestCommandErrorThing("overlaprect", "number", qs, qt, args[2])
+		}
+
+		if !heightFound {
+			return QuestCommandErrorThing("overlaprect", "number", qs, qt, args[3])
+		}
+
+		if CurrentMap == nil {
+			return QuestCommandErrorThing("overlaprect", "map", qs, qt, "CurrentMap")
+		}
+
+		hits := CurrentMap.World.OverlapRect(rl.RectangleInt32{
+			X:      int32(pos.X),
+			Y:      int32(pos.Y),
+			Width:  int32(width),
+			Height: int32(height),
+		}, RetrieveCollisionMask(args[4:]))
+
+		qs.SetVariable(args[0], float64(len(hits)))
+		return true
+	})
+
+	// overlapcircle <count> <center> <radius> [layers...]
+	q.RegisterCommand("overlapcircle", func(qs *Quest, qt *QuestTask, args []string) bool {
+		if len(args) < 3 {
+			return QuestCommandErrorArgCount("overlapcircle", qs, qt, len(args), 3)
+		}
+
+		center, centerFound := qs.GetVector(args[1])
+		radius, radiusFound := qs.GetNumberOrVariable(args[2])
+
+		if !centerFound {
+			return QuestCommandErrorThing("overlapcircle", "vector", qs, qt, args[1])
+		}
+
+		if !radiusFound {
+			return QuestCommandErrorThing("overlapcircle", "number", qs, qt, args[2])
+		}
+
+		if CurrentMap == nil {
+			return QuestCommandErrorThing("overlapcircle", "map", qs, qt, "CurrentMap")
+		}
+
+		hits := CurrentMap.World.OverlapCircle(center, float64to32(radius), RetrieveCollisionMask(args[3:]))
+
+		qs.SetVariable(args[0], float64(len(hits)))
+		return true
+	})
+}
+
+// questSetQueryHit stores the hit distance (-1 if nothing was hit) and the impact point
+func questSetQueryHit(qs *Quest, distName, pointName string, hit QueryHit, ok bool) {
+	if !ok {
+		hit.Distance = -1
+	}
+
+	if distName != "0" {
+		qs.SetVariable(distName, float64(hit.Distance))
+	}
+
+	if pointName != "0" {
+		qs.SetVector(pointName, hit.Point)
+	}
+}
@@ -1,5 +1,7 @@
-package core
-
-func questInitCommands(q *QuestManager) {
-	questInitMathCommands(q)
-}
+package core
+
+func questInitCommands(q *QuestManager) {
+	questInitMathCommands(q)
+	questInitQueryCommands(q)
+	questInitParticleCommands(q)
+}
@@ -276,6 +276,17 @@ func (qs *Quest) ProcessVariables() {
 	qs.SetVariable("$step", float64(stepCounter))
 	qs.SetVariable("$time", float64(rl.GetTime()))
 
+	// clock
+	qs.SetVariable("$day", float64(GameClock.Day))
+	qs.SetVariable("$hour", float64(GameClock.Hour()))
+	qs.SetVariable("$minute", float64(GameClock.Minute()))
+
+	if IsNight() {
+		qs.SetVariable("$night", 1)
+	} else {
+		qs.SetVariable("$night", 0)
+	}
+
 	// player
 	qs.SetVector("$pc.position", LocalPlayer.Position)
 
@@ -1,337 +1,726 @@
-/*
-   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
-
-   Licensed under the Apache License, Version 2.0 (the "License");
-   you may not use this file except in compliance with the License.
-   You may obtain a copy of the License at
-
-       http://www.apache.org/licenses/LICENSE-2.0
-
-   Unless required by applicable law or agreed to in writing, software
-   distributed under the License is distributed on an "AS IS" BASIS,
-   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
-   See the License for the specific language governing permissions and
-   limitations under the License.
-*/
-
-package core
-
-import (
-	"fmt"
-	"log"
-	"reflect"
-
-	jsoniter "github.com/json-iterator/go"
-	"github.com/robertkrimen/otto"
-	rl "github.com/zaklaus/raylib-go/raylib"
-	"github.com/zaklaus/rurik/src/system"
-)
-
-// InvokeData is the incoming data from the DSL caller
-type InvokeData interface{}
-
-var (
-	// Natives consists of registered methods you can invoke from the scripting side
-	Natives map[string]func(data InvokeData) interface{}
-
-	// EventHandlers consists of handlers for a particular scriptable event
-	EventHandlers map[string][]otto.Value
-
-	// ScriptingContext is a scripting ScriptingContext
-	ScriptingContext *otto.Otto
-
-	// InitUserEvents is called to extend the API with user events
-	InitUserEvents func()
-)
-
-func initDefaultEvents() {
-	Natives = make(map[string]func(data InvokeData) interface{})
-	EventHandlers = make(map[string][]otto.Value)
-
-	RegisterNative("exitGame", func(in InvokeData) interface{} {
-		CloseGame()
-		return nil
-	})
-
-	RegisterNative("followPlayer", func(in InvokeData) interface{} {
-		var data struct{ Speed float64 }
-		DecodeInvokeData(&data, in)
-
-		if data.Speed != 0 {
-			MainCamera.Speed = float32(data.Speed)
-		}
-
-		MainCamera.Mode = CameraModeFollow
-		MainCamera.Follow = LocalPlayer
-		return nil
-	})
-
-	RegisterNative("cameraInterpolate", func(in InvokeData) interface{} {
-		var data struct {
-			Speed   float64
-			Start   string
-			End     string
-			Instant bool
-		}
-		DecodeInvokeData(&data, in)
-
-		if data.Speed != 0 {
-			MainCamera.Speed = float32(data.Speed)
-		}
-
-		if data.Instant {
-			MainCamera.First = true
-		}
-
-		MainCamera.Mode = CameraModeLerp
-		MainCamera.Start, _ = CurrentMap.World.FindObject(data.Start)
-		MainCamera.End, _ = CurrentMap.World.FindObject(data.End)
-
-		return nil
-	})
-
-	RegisterNative("testReturnValue", func(in InvokeData) interface{} {
-		return struct {
-			Foo string
-			Bar int32
-		}{
-			"hey",
-			123,
-		}
-	})
-
-	RegisterNative("quest", func(jsData InvokeData) interface{} {
-		var data struct {
-			ID        int64
-			EventName string
-			Args      []float64
-		}
-		data.ID = -1
-
-		DecodeInvokeData(&data, jsData)
-
-		Quests.CallEvent(data.ID, data.EventName, data.Args)
-		return nil
-	})
-
-	RegisterNative("addQuest", func(jsData InvokeData) interface{} {
-		var data struct {
-			Name string
-		}
-		DecodeInvokeData(&data, jsData)
-
-		_, _, id := Quests.AddQuest(data.Name, nil)
-		return id
-	})
-
-	if InitUserEvents != nil {
-		InitUserEvents()
-	}
-}
-
-// DecodeInvokeData decodes incoming data from the script
-func DecodeInvokeData(data interface{}, in InvokeData) {
-	inp := in.(map[string]interface{})
-	ref := reflect.ValueOf(data).Elem()
-	dataName := reflect.TypeOf(data).Elem().Name()
-
-	for k, v := range inp {
-		fieldSource := reflect.ValueOf(v)
-		fieldDest := ref.FieldByName(k)
-
-		if fieldDest.IsValid() && fieldDest.CanSet() {
-			if fieldDest.Type() == fieldSource.Type() {
-				fieldDest.Set(fieldSource)
-			} else {
-				log.Printf(
-					"Property %s of type %s has a different type %s inside of %s !\n",
-					k,
-					fieldSource.Type().String(),
-					fieldDest.Type().String(),
-					dataName,
-				)
-			}
-		} else {
-			log.Printf("Property %s not found inside of %s while invoking an event!\n", k, dataName)
-		}
-	}
-}
-
-func initScriptingSystem() {
-	initDefaultEvents()
-
-	ScriptingContext = otto.New()
-
-	ScriptingContext.Set("log", func(call otto.FunctionCall) otto.Value {
-		obj := call.Argument(0)
-		fmt.Println(obj)
-
-		return otto.Value{}
-	})
-
-	ScriptingContext.Set("findObject", func(call otto.FunctionCall) otto.Value {
-		arg, _ := call.Argument(0).ToString()
-		wv, _ := ScriptingContext.Get("CurrentWorld")
-		w, _ := wv.Export()
-		obj, _ := w.(*World).FindObject(arg)
-		ret, _ := ScriptingContext.ToValue(obj)
-		return ret
-	})
-
-	ScriptingContext.Set("setProperty", func(call otto.FunctionCall) otto.Value {
-		source, _ := call.Argument(0).Export()
-		field, _ := call.Argument(1).ToString()
-		value, _ := call.Argument(2).Export()
-		v := reflect.ValueOf(source)
-		vd := reflect.ValueOf(value)
-		r := reflect.Indirect(v).FieldByName(field)
-		r.Set(vd)
-
-		return otto.Value{}
-	})
-
-	ScriptingContext.Set("exitGame", func(call otto.FunctionCall) otto.Value {
-		CloseGame()
-		return otto.Value{}
-	})
-
-	ScriptingContext.Set("invoke", func(call otto.FunctionCall) otto.Value {
-		eventName, _ := call.Argument(0).ToString()
-
-		event, ok := Natives[eventName]
-
-		if !ok {
-			log.Printf("Can't invoke event '%s'!\n", eventName)
-			return otto.Value{}
-		}
-
-		var eventData InvokeData
-
-		if len(call.ArgumentList) > 1 {
-			eventData, _ = call.Argument(1).Export()
-		}
-
-		res := event(eventData)
-		tryConv, done := otto.ToValue(res)
-		if done == nil {
-			return tryConv
-		}
-
-		retObj, err := jsoniter.MarshalToString(&res)
-		if err != nil {
-			log.Printf("Invalid invoke return value! %v\n", err)
-		}
-
-		ret, _ := ScriptingContext.Object(fmt.Sprintf("(%s)", retObj))
-
-		return ret.Value()
-	})
-
-	ScriptingContext.Set("addEventHandler", func(call otto.FunctionCall) otto.Value {
-		eventName := call.Argument(0).String()
-		eventHandler := call.Argument(1)
-
-		eventHandlers, ok := EventHandlers[eventName]
-		if !ok {
-			eventHandlers = []otto.Value{}
-		}
-
-		eventHandlers = append(eventHandlers, eventHandler)
-		EventHandlers[eventName] = eventHandlers
-
-		return otto.Value{}
-	})
-
-	ScriptingContext.Set("getObjectsOfType", func(call otto.FunctionCall) otto.Value {
-		className := call.Argument(0).String()
-		avoidType := false
-
-		if len(call.ArgumentList) > 1 {
-			avoidType, _ = call.Argument(1).ToBoolean()
-		}
-
-		allObjects := []*Object{}
-
-		for _, v := range Maps {
-			allObjects = append(allObjects, v.World.GetObjectsOfType(className, avoidType)...)
-		}
-
-		ret, _ := otto.ToValue(allObjects)
-		return ret
-	})
-
-	ScriptingContext.Set("newColor", func(call otto.FunctionCall) otto.Value {
-		r32, _ := call.Argument(0).ToInteger()
-		r := uint8(r32)
-		g32, _ := call.Argument(1).ToInteger()
-		g := uint8(g32)
-		b32, _ := call.Argument(2).ToInteger()
-		b := uint8(b32)
-		a32, _ := call.Argument(3).ToInteger()
-		a := uint8(a32)
-
-		ret, _ := otto.ToValue(rl.NewColor(r, g, b, a))
-		return ret
-	})
-
-	ScriptingContext.Set("fireEvent", func(call otto.FunctionCall) otto.Value {
-		eventName := call.Argument(0).String()
-
-		fireEventOtto(eventName, call.ArgumentList[1:]...)
-
-		return otto.Value{}
-	})
-
-	ScriptingContext.Object("global = {}")
-}
-
-// RegisterNative registers a particular method
-func RegisterNative(name string, call func(data InvokeData) interface{}) {
-	Natives[name] = call
-}
-
-// FireEvent triggers an event of a particular name
-func FireEvent(name string, data ...interface{}) {
-	handlers, ok := EventHandlers[name]
-
-	if ok {
-		updateScriptingContext()
-		scriptingProfiler.StartInvocation()
-		for _, v := range handlers {
-			v.Call(v, data)
-		}
-		scriptingProfiler.StopInvocation()
-	}
-}
-
-func fireEventOtto(name string, data ...otto.Value) {
-	handlers, ok := EventHandlers[name]
-
-	if ok {
-		updateScriptingContext()
-		scriptingProfiler.StartInvocation()
-		for _, v := range handlers {
-			v.Call(v, data)
-		}
-		scriptingProfiler.StopInvocation()
-	}
-}
-
-func updateScriptingContext() {
-	ScriptingContext.Set("FrameTime", system.FrameTime*float32(TimeScale))
-	ScriptingContext.Set("TotalTime", rl.GetTime()*float32(TimeScale))
-	ScriptingContext.Set("LocalPlayer", LocalPlayer)
-	ScriptingContext.Set("MainCamera", MainCamera)
-	ScriptingContext.Set("CurrentMap", CurrentMap)
-	ScriptingContext.Set("CanSave", CanSave)
-	ScriptingContext.Set("CurrentGameMode", CurrentGameMode)
-
-	if CurrentMap != nil {
-		ScriptingContext.Set("CurrentWorld", CurrentMap.World)
-	} else {
-		ScriptingContext.Set("CurrentWorld", nil)
-	}
-
-	ScriptingContext.Set("Self", nil)
-	ScriptingContext.Set("Instigator", nil)
-}
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/robertkrimen/otto"
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/rurik/src/system"
+)
+
+// InvokeData is the incoming data from the DSL caller
+type InvokeData interface{}
+
+var (
+	// Natives consists of registered methods you can invoke from the scripting side
+	Natives map[string]func(data InvokeData) interface{}
+
+	// EventHandlers consists of handlers for a particular scriptable event
+	EventHandlers map[string][]otto.Value
+
+	// ScriptingContext is a scripting ScriptingContext
+	ScriptingContext *otto.Otto
+
+	// InitUserEvents is called to extend the API with user events
+	InitUserEvents func()
+)
+
+func initDefaultEvents() {
+	Natives = make(map[string]func(data InvokeData) interface{})
+	EventHandlers = make(map[string][]otto.Value)
+
+	RegisterNative("exitGame", func(in InvokeData) interface{} {
+		CloseGame()
+		return nil
+	})
+
+	RegisterNative("followPlayer", func(in InvokeData) interface{} {
+		var data struct{ Speed float64 }
+		DecodeInvokeData(&data, in)
+
+		if data.Speed != 0 {
+			MainCamera.Speed = float32(data.Speed)
+		}
+
+		MainCamera.Mode = CameraModeFollow
+		MainCamera.Follow = LocalPlayer
+		return nil
+	})
+
+	RegisterNative("cameraInterpolate", func(in InvokeData) interface{} {
+		var data struct {
+			Speed   float64
+			Start   string
+			End     string
+			Instant bool
+		}
+		DecodeInvokeData(&data, in)
+
+		if data.Speed != 0 {
+			MainCamera.Speed = float32(data.Speed)
+		}
+
+		if data.Instant {
+			MainCamera.First = true
+		}
+
+		MainCamera.Mode = CameraModeLerp
+		MainCamera.Start, _ = CurrentMap.World.FindObject(data.Start)
+		MainCamera.End, _ = CurrentMap.World.FindObject(data.End)
+
+		return nil
+	})
+
+	RegisterNative("cameraFollow", func(in InvokeData) interface{} {
+		var data struct {
+			Target    string
+			Speed     float64
+			DeadZoneX float64
+			DeadZoneY float64
+			LookAhead float64
+		}
+		DecodeInvokeData(&data, in)
+
+		target := LocalPlayer
+
+		if data.Target != "" {
+			target, _ = CurrentMap.World.FindObject(data.Target)
+		}
+
+		if data.Speed != 0 {
+			MainCamera.Speed = float32(data.Speed)
+		}
+
+		MainCamera.DeadZone = rl.NewVector2(float32(data.DeadZoneX), float32(data.DeadZoneY))
+		MainCamera.LookAhead = float32(data.LookAhead)
+		MainCamera.SetCameraFollow(target)
+		return nil
+	})
+
+	RegisterNative("cameraRail", func(in InvokeData) interface{} {
+		var data struct {
+			Path     string
+			Duration float64
+			Easing   string
+		}
+		DecodeInvokeData(&data, in)
+
+		MainCamera.SetCameraRail(data.Path, float32(data.Duration), data.Easing)
+		return nil
+	})
+
+	RegisterNative("cameraFrame", func(in InvokeData) interface{} {
+		var data struct {
+			Targets string
+			Padding float64
+			MinZoom float64
+			MaxZoom float64
+		}
+		DecodeInvokeData(&data, in)
+
+		if data.Padding != 0 {
+			MainCamera.FramePadding = float32(data.Padding)
+		}
+
+		if data.MinZoom != 0 {
+			MainCamera.MinZoom = float32(data.MinZoom)
+		}
+
+		if data.MaxZoom != 0 {
+			MainCamera.MaxZoom = float32(data.MaxZoom)
+		}
+
+		MainCamera.SetCameraTargets(splitNameList(data.Targets))
+		return nil
+	})
+
+	RegisterNative("cameraBounds", func(in InvokeData) interface{} {
+		var data struct {
+			Name   string
+			X      float64
+			Y      float64
+			Width  float64
+			Height float64
+			Clear  bool
+		}
+		DecodeInvokeData(&data, in)
+
+		if data.Clear {
+			MainCamera.ClearCameraBounds()
+		} else if data.Name != "" {
+			MainCamera.SetCameraBounds(data.Name)
+		} else {
+			MainCamera.SetCameraBoundsRect(rl.NewRectangle(float32(data.X), float32(data.Y), float32(data.Width), float32(data.Height)))
+		}
+
+		return nil
+	})
+
+	RegisterNative("cameraShake", func(in InvokeData) interface{} {
+		var data struct{ Trauma float64 }
+		DecodeInvokeData(&data, in)
+
+		MainCamera.AddCameraTrauma(float32(data.Trauma))
+		return nil
+	})
+
+	RegisterNative("cameraZoom", func(in InvokeData) interface{} {
+		var data struct {
+			Zoom    float64
+			Speed   float64
+			Instant bool
+		}
+		DecodeInvokeData(&data, in)
+
+		if data.Speed != 0 {
+			MainCamera.ZoomSpeed = float32(data.Speed)
+		}
+
+		if data.Instant {
+			MainCamera.SetCameraZoom(float32(data.Zoom))
+		} else {
+			MainCamera.TargetZoom = float32(data.Zoom)
+		}
+
+		return nil
+	})
+
+	RegisterNative("cameraPush", func(in InvokeData) interface{} {
+		var data struct {
+			Name  string
+			Blend float64
+		}
+		DecodeInvokeData(&data, in)
+
+		cam, _ := CurrentMap.World.FindObject(data.Name)
+
+		if cam == nil || !cam.HasComponent("camera") {
+			log.Printf("Camera '%s' could not be found!\n", data.Name)
+			return nil
+		}
+
+		PushCamera(cam, float32(data.Blend))
+		return nil
+	})
+
+	RegisterNative("cameraPop", func(in InvokeData) interface{} {
+		var data struct{ Blend float64 }
+		DecodeInvokeData(&data, in)
+
+		PopCamera(float32(data.Blend))
+		return nil
+	})
+
+	RegisterNative("emitterStart", func(in InvokeData) interface{} {
+		var data struct{ Name string }
+		DecodeInvokeData(&data, in)
+
+		o := FindEmitter(data.Name)
+
+		if o == nil {
+			log.Printf("Object '%s' has no particle emitter!\n", data.Name)
+			return nil
+		}
+
+		o.Emitter.Start(o)
+		return nil
+	})
+
+	RegisterNative("emitterStop", func(in InvokeData) interface{} {
+		var data struct {
+			Name  string
+			Clear bool
+		}
+		DecodeInvokeData(&data, in)
+
+		o := FindEmitter(data.Name)
+
+		if o == nil {
+			log.Printf("Object '%s' has no particle emitter!\n", data.Name)
+			return nil
+		}
+
+		o.Emitter.Stop(data.Clear)
+		return nil
+	})
+
+	RegisterNative("emitterBurst", func(in InvokeData) interface{} {
+		var data struct {
+			Name  string
+			Count float64
+		}
+		DecodeInvokeData(&data, in)
+
+		o := FindEmitter(data.Name)
+
+		if o == nil {
+			log.Printf("Object '%s' has no particle emitter!\n", data.Name)
+			return nil
+		}
+
+		o.Emitter.Burst(o, int(data.Count))
+		return nil
+	})
+
+	RegisterNative("getTime", func(in InvokeData) interface{} {
+		stage, _ := GetDayStage()
+
+		return struct {
+			Day     int
+			Hour    int
+			Minute  int
+			Stage   string
+			IsNight bool
+		}{
+			GameClock.Day,
+			GameClock.Hour(),
+			GameClock.Minute(),
+			stage.Name,
+			stage.Night,
+		}
+	})
+
+	RegisterNative("setTime", func(in InvokeData) interface{} {
+		var data struct {
+			Day    float64
+			Hour   float64
+			Minute float64
+		}
+		DecodeInvokeData(&data, in)
+
+		if data.Day > 0 {
+			GameClock.Day = int(data.Day)
+		}
+
+		GameClock.SetTime(int(data.Hour), int(data.Minute))
+		return nil
+	})
+
+	RegisterNative("setWeather", func(in InvokeData) interface{} {
+		var data struct {
+			Name       string
+			Transition float64
+			Instant    bool
+		}
+		data.Transition = -1
+		DecodeInvokeData(&data, in)
+
+		if CurrentMap == nil {
+			return nil
+		}
+
+		if data.Instant {
+			data.Transition = 0
+		}
+
+		CurrentMap.Weather.SetWeather(data.Name, float32(data.Transition))
+		return nil
+	})
+
+	RegisterNative("playTimeline", func(in InvokeData) interface{} {
+		var data struct{ Name string }
+		DecodeInvokeData(&data, in)
+
+		return PlayTimeline(data.Name) != nil
+	})
+
+	RegisterNative("skipTimeline", func(in InvokeData) interface{} {
+		SkipTimeline()
+		return nil
+	})
+
+	RegisterNative("stopTimeline", func(in InvokeData) interface{} {
+		StopTimeline()
+		return nil
+	})
+
+	RegisterNative("isTimelinePlaying", func(in InvokeData) interface{} {
+		return IsTimelinePlaying()
+	})
+
+	RegisterNative("testReturnValue", func(in InvokeData) interface{} {
+		return struct {
+			Foo string
+			Bar int32
+		}{
+			"hey",
+			123,
+		}
+	})
+
+	RegisterNative("quest", func(jsData InvokeData) interface{} {
+		var data struct {
+			ID        int64
+			EventName string
+			Args      []float64
+		}
+		data.ID = -1
+
+		DecodeInvokeData(&data, jsData)
+
+		Quests.CallEvent(data.ID, data.EventName, data.Args)
+		return nil
+	})
+
+	RegisterNative("addQuest", func(jsData InvokeData) interface{} {
+		var data struct {
+			Name string
+		}
+		DecodeInvokeData(&data, jsData)
+
+		_, _, id := Quests.AddQuest(data.Name, nil)
+		return id
+	})
+
+	if InitUserEvents != nil {
+		InitUserEvents()
+	}
+}
+
+// DecodeInvokeData decodes incoming data from the script
+func DecodeInvokeData(data interface{}, in InvokeData) {
+	inp := in.(map[string]interface{})
+	ref := reflect.ValueOf(data).Elem()
+	dataName := reflect.TypeOf(data).Elem().Name()
+
+	for k, v := range inp {
+		fieldSource := reflect.ValueOf(v)
+		fieldDest := ref.FieldByName(k)
+
+		if fieldDest.IsValid() && fieldDest.CanSet() {
+			if fieldDest.Type() == fieldSource.Type() {
+				fieldDest.Set(fieldSource)
+			} else {
+				log.Printf(
+					"Property %s of type %s has a different type %s inside of %s !\n",
+					k,
+					fieldSource.Type().String(),
+					fieldDest.Type().String(),
+					dataName,
+				)
+			}
+		} else {
+			log.Printf("Property %s not found inside of %s while invoking an event!\n", k, dataName)
+		}
+	}
+}
+
+func initScriptingSystem() {
+	initDefaultEvents()
+
+	ScriptingContext = otto.New()
+
+	ScriptingContext.Set("log", func(call otto.FunctionCall) otto.Value {
+		obj := call.Argument(0)
+		fmt.Println(obj)
+
+		return otto.Value{}
+	})
+
+	ScriptingContext.Set("findObject", func(call otto.FunctionCall) otto.Value {
+		arg, _ := call.Argument(0).ToString()
+		wv, _ := ScriptingContext.Get("CurrentWorld")
+		w, _ := wv.Export()
+		obj, _ := w.(*World).FindObject(arg)
+		ret, _ := ScriptingContext.ToValue(obj)
+		return ret
+	})
+
+	ScriptingContext.Set("setProperty", func(call otto.FunctionCall) otto.Value {
+		source, _ := call.Argument(0).Export()
+		field, _ := call.Argument(1).ToString()
+		value, _ := call.Argument(2).Export()
+		v := reflect.ValueOf(source)
+		vd := reflect.ValueOf(value)
+		r := reflect.Indirect(v).FieldByName(field)
+		r.Set(vd)
+
+		return otto.Value{}
+	})
+
+	ScriptingContext.Set("exitGame", func(call otto.FunctionCall) otto.Value {
+		CloseGame()
+		return otto.Value{}
+	})
+
+	ScriptingContext.Set("invoke", func(call otto.FunctionCall) otto.Value {
+		eventName, _ := call.Argument(0).ToString()
+
+		event, ok := Natives[eventName]
+
+		if !ok {
+			log.Printf("Can't invoke event '%s'!\n", eventName)
+			return otto.Value{}
+		}
+
+		var eventData InvokeData
+
+		if len(call.ArgumentList) > 1 {
+			eventData, _ = call.Argument(1).Export()
+		}
+
+		res := event(eventData)
+		tryConv, done := otto.ToValue(res)
+		if done == nil {
+			return tryConv
+		}
+
+		retObj, err := jsoniter.MarshalToString(&res)
+		if err != nil {
+			log.Printf("Invalid invoke return value! %v\n", err)
+		}
+
+		ret, _ := ScriptingContext.Object(fmt.Sprintf("(%s)", retObj))
+
+		return ret.Value()
+	})
+
+	ScriptingContext.Set("addEventHandler", func(call otto.FunctionCall) otto.Value {
+		eventName := call.Argument(0).String()
+		eventHandler := call.Argument(1)
+
+		eventHandlers, ok := EventHandlers[eventName]
+		if !ok {
+			eventHandlers = []otto.Value{}
+		}
+
+		eventHandlers = append(eventHandlers, eventHandler)
+		EventHandlers[eventName] = eventHandlers
+
+		return otto.Value{}
+	})
+
+	ScriptingContext.Set("getObjectsOfType", func(call otto.FunctionCall) otto.Value {
+		className := call.Argument(0).String()
+		avoidType := false
+
+		if len(call.ArgumentList) > 1 {
+			avoidType, _ = call.Argument(1).ToBoolean()
+		}
+
+		allObjects := []*Object{}
+
+		for _, v := range Maps {
+			allObjects = append(allObjects, v.World.GetObjectsOfType(className, avoidType)...)
+		}
+
+		ret, _ := otto.ToValue(allObjects)
+		return ret
+	})
+
+	ScriptingContext.Set("newColor", func(call otto.FunctionCall) otto.Value {
+		r32, _ := call.Argument(0).ToInteger()
+		r := uint8(r32)
+		g32, _ := call.Argument(1).ToInteger()
+		g := uint8(g32)
+		b32, _ := call.Argument(2).ToInteger()
+		b := uint8(b32)
+		a32, _ := call.Argument(3).ToInteger()
+		a := uint8(a32)
+
+		ret, _ := otto.ToValue(rl.NewColor(r, g, b, a))
+		return ret
+	})
+
+	ScriptingContext.Set("fireEvent", func(call otto.FunctionCall) otto.Value {
+		eventName := call.Argument(0).String()
+
+		fireEventOtto(eventName, call.ArgumentList[1:]...)
+
+		return otto.Value{}
+	})
+
+	ScriptingContext.Set("raycast", func(call otto.FunctionCall) otto.Value {
+		from := ottoToVector2(call.Argument(0))
+		to := ottoToVector2(call.Argument(1))
+		mask := ottoToCollisionMask(call.Argument(2))
+
+		hit, ok := getScriptingWorld().Raycast(from, to, mask)
+
+		if !ok {
+			return otto.NullValue()
+		}
+
+		ret, _ := ScriptingContext.ToValue(hit)
+		return ret
+	})
+
+	ScriptingContext.Set("circleCast", func(call otto.FunctionCall) otto.Value {
+		from := ottoToVector2(call.Argument(0))
+		to := ottoToVector2(call.Argument(1))
+		radius, _ := call.Argument(2).ToFloat()
+		mask := ottoToCollisionMask(call.Argument(3))
+
+		hit, ok := getScriptingWorld().CircleCast(from, to, float32(radius), mask)
+
+		if !ok {
+			return otto.NullValue()
+		}
+
+		ret, _ := ScriptingContext.ToValue(hit)
+		return ret
+	})
+
+	ScriptingContext.Set("overlapRect", func(call otto.FunctionCall) otto.Value {
+		x, _ := call.Argument(0).ToInteger()
+		y, _ := call.Argument(1).ToInteger()
+		w, _ := call.Argument(2).ToInteger()
+		h, _ := call.Argument(3).ToInteger()
+		mask := ottoToCollisionMask(call.Argument(4))
+
+		hits := getScriptingWorld().OverlapRect(rl.RectangleInt32{
+			X:      int32(x),
+			Y:      int32(y),
+			Width:  int32(w),
+			Height: int32(h),
+		}, mask)
+
+		ret, _ := ScriptingContext.ToValue(hits)
+		return ret
+	})
+
+	ScriptingContext.Set("overlapCircle", func(call otto.FunctionCall) otto.Value {
+		center := ottoToVector2(call.Argument(0))
+		radius, _ := call.Argument(1).ToFloat()
+		mask := ottoToCollisionMask(call.Argument(2))
+
+		hits := getScriptingWorld().OverlapCircle(center, float32(radius), mask)
+
+		ret, _ := ScriptingContext.ToValue(hits)
+		return ret
+	})
+
+	ScriptingContext.Object("global = {}")
+}
+
+func getScriptingWorld() *World {
+	if CurrentMap == nil {
+		return &World{}
+	}
+
+	return CurrentMap.World
+}
+
+// ottoToVector2 accepts both exported vectors and plain {X, Y} objects
+func ottoToVector2(val otto.Value) rl.Vector2 {
+	data, _ := val.Export()
+
+	switch v := data.(type) {
+	case rl.Vector2:
+		return v
+	case *rl.Vector2:
+		return *v
+	case map[string]interface{}:
+		return rl.Vector2{
+			X: interfaceToFloat32(v["X"]),
+			Y: interfaceToFloat32(v["Y"]),
+		}
+	}
+
+	return rl.Vector2{}
+}
+
+// ottoToCollisionMask converts an array of collision layer names into a mask
+func ottoToCollisionMask(val otto.Value) uint32 {
+	data, _ := val.Export()
+	names := []string{}
+
+	switch v := data.(type) {
+	case []string:
+		names = v
+	case []interface{}:
+		for _, n := range v {
+			names = append(names, fmt.Sprintf("%v", n))
+		}
+	case string:
+		names = splitCollisionTypeNames(v)
+	}
+
+	return RetrieveCollisionMask(names)
+}
+
+func interfaceToFloat32(val interface{}) float32 {
+	switch v := val.(type) {
+	case float64:
+		return float32(v)
+	case float32:
+		return v
+	case int64:
+		return float32(v)
+	case int:
+		return float32(v)
+	}
+
+	return 0
+}
+
+// RegisterNative registers a particular method
+func RegisterNative(name string, call func(data InvokeData) interface{}) {
+	Natives[name] = call
+}
+
+// FireEvent triggers an event of a particular name
+func FireEvent(name string, data ...interface{}) {
+	handlers, ok := EventHandlers[name]
+
+	if ok {
+		updateScriptingContext()
+		scriptingProfiler.StartInvocation()
+		for _, v := range handlers {
+			v.Call(v, data)
+		}
+		scriptingProfiler.StopInvocation()
+	}
+}
+
+func fireEventOtto(name string, data ...otto.Value) {
+	handlers, ok := EventHandlers[name]
+
+	if ok {
+		updateScriptingContext()
+		scriptingProfiler.StartInvocation()
+		for _, v := range handlers {
+			v.Call(v, data)
+		}
+		scriptingProfiler.StopInvocation()
+	}
+}
+
+func updateScriptingContext() {
+	ScriptingContext.Set("FrameTime", system.FrameTime*float32(TimeScale))
+	ScriptingContext.Set("TotalTime", rl.GetTime()*float32(TimeScale))
+	ScriptingContext.Set("LocalPlayer", LocalPlayer)
+	ScriptingContext.Set("MainCamera", MainCamera)
+	ScriptingContext.Set("CurrentMap", CurrentMap)
+	ScriptingContext.Set("CanSave", CanSave)
+	ScriptingContext.Set("CurrentGameMode", CurrentGameMode)
+	ScriptingContext.Set("GameClock", &GameClock)
+
+	if CurrentMap != nil {
+		ScriptingContext.Set("CurrentWorld", CurrentMap.World)
+	} else {
+		ScriptingContext.Set("CurrentWorld", nil)
+	}
+
+	ScriptingContext.Set("Self", nil)
+	ScriptingContext.Set("Instigator", nil)
+}
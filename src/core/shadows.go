@@ -0,0 +1,265 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"math"
+	"sort"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/raylib-go/raymath"
+)
+
+var (
+	// ShadowsEnabled toggles shadow casting of all lights
+	ShadowsEnabled = true
+
+	// ShadowOccluderMask specifies the collision layers which block the light
+	ShadowOccluderMask = LayerBit(CollisionSolid) | LayerBit(CollisionRigid) | LayerBit(CollisionSlope)
+
+	// ShadowFalloffSteps is the number of rings approximating the light's falloff,
+	// the lightmap blur smooths them out along with the shadow edges
+	ShadowFalloffSteps = 8
+
+	// ShadowRaySpacing is the angle in degrees between the rays outlining the light's circle
+	ShadowRaySpacing = 10.0
+
+	// ShadowCircleSegments is the number of edges used to approximate circle occluders
+	ShadowCircleSegments = 12
+)
+
+// shadowSegment is an edge of the geometry blocking the light
+type shadowSegment struct {
+	a, b rl.Vector2
+}
+
+// drawShadowedLight draws the light within the angle span clipped by the visibility polygon,
+// circular lights without any occluders in reach are drawn as plain gradient circles
+func drawShadowedLight(o *Object, color rl.Color, from, span float64) {
+	pos := rl.Vector2{X: o.Position.X + o.Offset.X, Y: o.Position.Y + o.Offset.Y}
+	closed := span >= math.Pi*2
+	var segments []shadowSegment
+
+	if ShadowsEnabled && o.CastShadows {
+		segments = getShadowSegments(o, pos, o.Attenuation)
+	}
+
+	if len(segments) == 0 && closed {
+		rl.DrawCircleGradient(int32(pos.X), int32(pos.Y), o.Attenuation, color, rl.Blank)
+		return
+	}
+
+	poly := getVisibilityPolygon(pos, o.Attenuation, segments, from, span)
+	color.A = uint8(float32(color.A) / float32(ShadowFalloffSteps))
+
+	// each ring adds a fraction of the light, so the intensity falls off linearly with the distance
+	for k := 1; k <= ShadowFalloffSteps; k++ {
+		radius := o.Attenuation * float32(k) / float32(ShadowFalloffSteps)
+		drawVisibilityFan(pos, poly, radius, color, closed)
+	}
+}
+
+// getShadowSegments collects the edges of occluders within the light's reach
+func getShadowSegments(light *Object, pos rl.Vector2, radius float32) []shadowSegment {
+	segments := []shadowSegment{}
+
+	if light.world == nil {
+		return segments
+	}
+
+	reach := rl.RectangleInt32{
+		X:      int32(pos.X - radius),
+		Y:      int32(pos.Y - radius),
+		Width:  int32(radius*2) + 1,
+		Height: int32(radius*2) + 1,
+	}
+
+	for _, c := range light.world.QueryObjects(reach) {
+		if c == light || !isShadowOccluder(c) {
+			continue
+		}
+
+		if c.PolyLines != nil && c.CollisionType == CollisionSlope {
+			segments = appendPolyLineSegments(segments, c)
+			continue
+		}
+
+		s := getWorldShape(c, rl.Vector2{})
+
+		// lights placed inside of a collider would be fully shadowed
+		if isPointInShapeBounds(s, pos) {
+			continue
+		}
+
+		segments = appendShapeSegments(segments, s)
+	}
+
+	return segments
+}
+
+func isShadowOccluder(o *Object) bool {
+	return o.IsCollidable && o.GetCollisionLayers()&ShadowOccluderMask != 0
+}
+
+func isPointInShapeBounds(s worldShape, p rl.Vector2) bool {
+	b := s.bounds()
+	return p.X >= float32(b.X) && p.X <= float32(b.X+b.Width) && p.Y >= float32(b.Y) && p.Y <= float32(b.Y+b.Height)
+}
+
+func appendShapeSegments(segments []shadowSegment, s worldShape) []shadowSegment {
+	points := s.points
+
+	if s.isCircle {
+		points = make([]rl.Vector2, ShadowCircleSegments)
+
+		for i := range points {
+			a := float64(i) / float64(ShadowCircleSegments) * math.Pi * 2
+			points[i] = rl.Vector2{
+				X: s.center.X + float32(math.Cos(a))*s.radius,
+				Y: s.center.Y + float32(math.Sin(a))*s.radius,
+			}
+		}
+	}
+
+	for i := range points {
+		segments = append(segments, shadowSegment{points[i], points[(i+1)%len(points)]})
+	}
+
+	return segments
+}
+
+func appendPolyLineSegments(segments []shadowSegment, o *Object) []shadowSegment {
+	for _, pl := range o.PolyLines {
+		pts := *pl.Points
+
+		for i := 0; i < len(pts)-1; i++ {
+			segments = append(segments, shadowSegment{
+				rl.Vector2{X: o.Position.X + float32(pts[i].X), Y: o.Position.Y + float32(pts[i].Y)},
+				rl.Vector2{X: o.Position.X + float32(pts[i+1].X), Y: o.Position.Y + float32(pts[i+1].Y)},
+			})
+		}
+	}
+
+	return segments
+}
+
+// getVisibilityPolygon casts rays within the angle span towards the occluders' corners and along the light's circle,
+// returning the closest hits ordered by their angle
+func getVisibilityPolygon(pos rl.Vector2, radius float32, segments []shadowSegment, from, span float64) []rl.Vector2 {
+	angles := []float64{}
+	spacing := ShadowRaySpacing * math.Pi / 180
+
+	for a := 0.0; a < span; a += spacing {
+		angles = append(angles, a)
+	}
+
+	if span < math.Pi*2 {
+		angles = append(angles, span)
+	}
+
+	for _, s := range segments {
+		for _, p := range [2]rl.Vector2{s.a, s.b} {
+			a := math.Atan2(float64(p.Y-pos.Y), float64(p.X-pos.X))
+
+			// rays slightly off the corners reach the geometry behind it
+			for _, v := range [3]float64{a - 0.0001, a, a + 0.0001} {
+				if v = math.Mod(math.Mod(v-from, math.Pi*2)+math.Pi*2, math.Pi*2); v <= span {
+					angles = append(angles, v)
+				}
+			}
+		}
+	}
+
+	sort.Float64s(angles)
+	poly := make([]rl.Vector2, 0, len(angles))
+
+	for _, a := range angles {
+		dir := rl.Vector2{X: float32(math.Cos(from + a)), Y: float32(math.Sin(from + a))}
+		dist := radius
+
+		for _, s := range segments {
+			if t, ok := raySegmentIntersection(pos, dir, s); ok && t < dist {
+				dist = t
+			}
+		}
+
+		raymath.Vector2Scale(&dir, dist)
+		poly = append(poly, raymath.Vector2Add(pos, dir))
+	}
+
+	return poly
+}
+
+// raySegmentIntersection returns the distance along the normalized ray direction to the segment
+func raySegmentIntersection(from, dir rl.Vector2, s shadowSegment) (float32, bool) {
+	edge := raymath.Vector2Subtract(s.b, s.a)
+	den := dir.X*edge.Y - dir.Y*edge.X
+
+	if den == 0 {
+		return 0, false
+	}
+
+	diff := raymath.Vector2Subtract(s.a, from)
+	t := (diff.X*edge.Y - diff.Y*edge.X) / den
+	u := (diff.X*dir.Y - diff.Y*dir.X) / den
+
+	if t < 0 || u < 0 || u > 1 {
+		return 0, false
+	}
+
+	return t, true
+}
+
+// drawVisibilityFan fills the visibility polygon limited to the given radius, open polygons form a cone
+func drawVisibilityFan(pos rl.Vector2, poly []rl.Vector2, radius float32, color rl.Color, closed bool) {
+	clamp := func(p rl.Vector2) rl.Vector2 {
+		d := raymath.Vector2Subtract(p, pos)
+
+		if l := raymath.Vector2Length(d); l > radius {
+			raymath.Vector2Scale(&d, radius/l)
+			return raymath.Vector2Add(pos, d)
+		}
+
+		return p
+	}
+
+	for i := range poly {
+		if i == len(poly)-1 && !closed {
+			break
+		}
+
+		drawTriangleCCW(pos, clamp(poly[i]), clamp(poly[(i+1)%len(poly)]), color)
+	}
+}
+
+// drawTriangleCCW draws the triangle regardless of its winding, raylib culls clockwise triangles
+func drawTriangleCCW(a, b, c rl.Vector2, color rl.Color) {
+	if (b.X-a.X)*(c.Y-a.Y)-(b.Y-a.Y)*(c.X-a.X) > 0 {
+		b, c = c, b
+	}
+
+	rl.DrawTriangle(a, b, c, color)
+}
+
+// isLightWithinFrustum checks whether the light's reach overlaps the camera's view
+func isLightWithinFrustum(o *Object, radius float32) bool {
+	if !cullingEnabled {
+		return true
+	}
+
+	return rectanglesOverlap(getLightBounds(o, radius), GetFrustumRectangle())
+}
@@ -0,0 +1,472 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"log"
+	"math"
+	"sort"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/raylib-go/raymath"
+)
+
+const (
+	// ShapeRectangle is a rectangle rotated along with its object
+	ShapeRectangle uint32 = iota
+	// ShapePolygon is a convex polygon
+	ShapePolygon
+	// ShapeCircle is a circle
+	ShapeCircle
+)
+
+var (
+	// SweepBisections sets the precision of the time of impact search
+	SweepBisections = 8
+)
+
+// CollisionShape is a precise collision shape relative to the object's position.
+// Objects without a shape collide using their AABB.
+type CollisionShape struct {
+	Type   uint32
+	Points []rl.Vector2
+	Center rl.Vector2
+	Radius float32
+}
+
+// Contact describes how an object hits another one
+type Contact struct {
+	Object *Object
+
+	// Normal points away from the other object
+	Normal rl.Vector2
+
+	// Depth is the penetration depth along the normal
+	Depth float32
+
+	// Time is the fraction of the movement done before the impact
+	Time float32
+
+	// Movement is the movement allowed by the contact
+	Movement rl.Vector2
+}
+
+// NewRectangleShape creates a rectangle shape
+func NewRectangleShape(x, y, width, height float32) *CollisionShape {
+	return &CollisionShape{
+		Type: ShapeRectangle,
+		Points: []rl.Vector2{
+			{X: x, Y: y},
+			{X: x + width, Y: y},
+			{X: x + width, Y: y + height},
+			{X: x, Y: y + height},
+		},
+	}
+}
+
+// NewPolygonShape creates a convex polygon shape, concave polygons are replaced by their convex hull
+func NewPolygonShape(points []rl.Vector2) *CollisionShape {
+	if n := len(points); n > 1 && points[0] == points[n-1] {
+		points = points[:n-1]
+	}
+
+	hull := convexHull(points)
+
+	if len(hull) < 3 {
+		log.Printf("Polygon shape needs at least 3 distinct points!\n")
+	} else if len(hull) < len(points) {
+		log.Printf("Polygon shape is not convex, using its convex hull instead!\n")
+	}
+
+	return &CollisionShape{
+		Type:   ShapePolygon,
+		Points: hull,
+	}
+}
+
+// NewCircleShape creates a circle shape
+func NewCircleShape(x, y, radius float32) *CollisionShape {
+	return &CollisionShape{
+		Type:   ShapeCircle,
+		Center: rl.Vector2{X: x, Y: y},
+		Radius: radius,
+	}
+}
+
+// getShapeFromMeta builds a shape out of the Tiled object's geometry
+func getShapeFromMeta(o *Object) *CollisionShape {
+	if o.Meta == nil {
+		return nil
+	}
+
+	if len(o.Meta.Ellipses) > 0 {
+		radius := float32(math.Min(o.Meta.Width, o.Meta.Height) / 2)
+		return NewCircleShape(float32(o.Meta.Width/2), float32(o.Meta.Height/2), radius)
+	}
+
+	if len(o.Meta.Polygons) > 0 && o.Meta.Polygons[0].Points != nil {
+		points := []rl.Vector2{}
+
+		for _, p := range *o.Meta.Polygons[0].Points {
+			points = append(points, rl.Vector2{X: float32(p.X), Y: float32(p.Y)})
+		}
+
+		return NewPolygonShape(points)
+	}
+
+	if o.Meta.Rotation != 0 {
+		return NewRectangleShape(0, 0, float32(o.Meta.Width), float32(o.Meta.Height))
+	}
+
+	return nil
+}
+
+// worldShape is a shape transformed into world space
+type worldShape struct {
+	points   []rl.Vector2
+	center   rl.Vector2
+	radius   float32
+	isCircle bool
+}
+
+func getWorldShape(o *Object, offset rl.Vector2) worldShape {
+	if o.Shape == nil {
+		rec := o.GetAABB(o)
+		x := float32(rec.X) + offset.X
+		y := float32(rec.Y) + offset.Y
+		w := float32(rec.Width)
+		h := float32(rec.Height)
+
+		return worldShape{
+			points: []rl.Vector2{{X: x, Y: y}, {X: x + w, Y: y}, {X: x + w, Y: y + h}, {X: x, Y: y + h}},
+			center: rl.Vector2{X: x + w/2, Y: y + h/2},
+		}
+	}
+
+	sinR := float32(math.Sin(float64(o.Rotation) * math.Pi / 180))
+	cosR := float32(math.Cos(float64(o.Rotation) * math.Pi / 180))
+
+	transform := func(p rl.Vector2) rl.Vector2 {
+		return rl.Vector2{
+			X: p.X*cosR - p.Y*sinR + o.Position.X + offset.X,
+			Y: p.X*sinR + p.Y*cosR + o.Position.Y + offset.Y,
+		}
+	}
+
+	if o.Shape.Type == ShapeCircle {
+		return worldShape{
+			center:   transform(o.Shape.Center),
+			radius:   o.Shape.Radius,
+			isCircle: true,
+		}
+	}
+
+	s := worldShape{
+		points: make([]rl.Vector2, len(o.Shape.Points)),
+	}
+
+	for i, p := range o.Shape.Points {
+		s.points[i] = transform(p)
+		s.center.X += s.points[i].X
+		s.center.Y += s.points[i].Y
+	}
+
+	if len(s.points) > 0 {
+		s.center.X /= float32(len(s.points))
+		s.center.Y /= float32(len(s.points))
+	}
+
+	return s
+}
+
+// GetShapeBounds returns the world space bounding box of the object's collision shape
+func GetShapeBounds(o *Object) rl.RectangleInt32 {
+	return getWorldShape(o, rl.Vector2{}).bounds()
+}
+
+func (s worldShape) bounds() rl.RectangleInt32 {
+	if s.isCircle {
+		return rl.RectangleInt32{
+			X:      int32(math.Floor(float64(s.center.X - s.radius))),
+			Y:      int32(math.Floor(float64(s.center.Y - s.radius))),
+			Width:  int32(math.Ceil(float64(s.radius * 2))),
+			Height: int32(math.Ceil(float64(s.radius * 2))),
+		}
+	}
+
+	if len(s.points) == 0 {
+		return rl.RectangleInt32{X: int32(s.center.X), Y: int32(s.center.Y)}
+	}
+
+	minP, maxP := s.points[0], s.points[0]
+
+	for _, p := range s.points[1:] {
+		minP.X = float32(math.Min(float64(minP.X), float64(p.X)))
+		minP.Y = float32(math.Min(float64(minP.Y), float64(p.Y)))
+		maxP.X = float32(math.Max(float64(maxP.X), float64(p.X)))
+		maxP.Y = float32(math.Max(float64(maxP.Y), float64(p.Y)))
+	}
+
+	x := int32(math.Floor(float64(minP.X)))
+	y := int32(math.Floor(float64(minP.Y)))
+
+	return rl.RectangleInt32{
+		X:      x,
+		Y:      y,
+		Width:  int32(math.Ceil(float64(maxP.X))) - x,
+		Height: int32(math.Ceil(float64(maxP.Y))) - y,
+	}
+}
+
+// extent returns the smallest half-size of the shape, used to pick the sweep step
+func (s worldShape) extent() float32 {
+	if s.isCircle {
+		return s.radius
+	}
+
+	b := s.bounds()
+	return float32(math.Min(float64(b.Width), float64(b.Height))) / 2
+}
+
+func (s worldShape) project(axis rl.Vector2) (float32, float32) {
+	if s.isCircle {
+		c := raymath.Vector2DotProduct(s.center, axis)
+		return c - s.radius, c + s.radius
+	}
+
+	lo := raymath.Vector2DotProduct(s.points[0], axis)
+	hi := lo
+
+	for _, p := range s.points[1:] {
+		d := raymath.Vector2DotProduct(p, axis)
+
+		if d < lo {
+			lo = d
+		} else if d > hi {
+			hi = d
+		}
+	}
+
+	return lo, hi
+}
+
+// axes returns the edge normals of a polygon, or the axis towards the closest vertex for circles
+func (s worldShape) axes(other worldShape) []rl.Vector2 {
+	if s.isCircle {
+		if other.isCircle {
+			return []rl.Vector2{getShapeAxis(raymath.Vector2Subtract(other.center, s.center))}
+		}
+
+		closest := other.points[0]
+		dist := raymath.Vector2Distance(closest, s.center)
+
+		for _, p := range other.points[1:] {
+			if d := raymath.Vector2Distance(p, s.center); d < dist {
+				closest = p
+				dist = d
+			}
+		}
+
+		return []rl.Vector2{getShapeAxis(raymath.Vector2Subtract(closest, s.center))}
+	}
+
+	axes := make([]rl.Vector2, 0, len(s.points))
+
+	for i := range s.points {
+		p0 := s.points[i]
+		p1 := s.points[(i+1)%len(s.points)]
+		edge := raymath.Vector2Subtract(p1, p0)
+		axes = append(axes, getShapeAxis(rl.Vector2{X: -edge.Y, Y: edge.X}))
+	}
+
+	return axes
+}
+
+// getShapeAxis normalizes the axis, degenerate axes are kept zero instead of dividing by zero
+func getShapeAxis(axis rl.Vector2) rl.Vector2 {
+	if axis.X != 0 || axis.Y != 0 {
+		raymath.Vector2Normalize(&axis)
+	}
+
+	return axis
+}
+
+// testShapes performs a separating axis test, the normal points from b towards a
+func testShapes(a, b worldShape) (rl.Vector2, float32, bool) {
+	if (!a.isCircle && len(a.points) < 3) || (!b.isCircle && len(b.points) < 3) {
+		return rl.Vector2{}, 0, false
+	}
+
+	var normal rl.Vector2
+	depth := float32(math.MaxFloat32)
+
+	for _, axis := range append(a.axes(b), b.axes(a)...) {
+		if axis.X == 0 && axis.Y == 0 {
+			continue
+		}
+
+		minA, maxA := a.project(axis)
+		minB, maxB := b.project(axis)
+		overlap := float32(math.Min(float64(maxA), float64(maxB)) - math.Max(float64(minA), float64(minB)))
+
+		if overlap <= 0 {
+			return rl.Vector2{}, 0, false
+		}
+
+		if overlap < depth {
+			depth = overlap
+			normal = axis
+		}
+	}
+
+	// NOTE: concentric circles have no axis to test
+	if depth == math.MaxFloat32 {
+		normal = rl.Vector2{Y: -1}
+		depth = a.radius + b.radius
+	}
+
+	if raymath.Vector2DotProduct(raymath.Vector2Subtract(a.center, b.center), normal) < 0 {
+		normal = rl.Vector2{X: -normal.X, Y: -normal.Y}
+	}
+
+	return normal, depth, true
+}
+
+// sweepShape moves the object along the delta and finds the first impact with the other shape.
+// The movement is split into steps smaller than the shapes, so fast movers can't tunnel through.
+func sweepShape(o *Object, other worldShape, delta rl.Vector2) (Contact, bool) {
+	start := getWorldShape(o, rl.Vector2{})
+
+	if normal, depth, ok := testShapes(start, other); ok {
+		// NOTE: already penetrating, push out along the normal and slide
+		into := float32(math.Min(float64(raymath.Vector2DotProduct(delta, normal)), 0))
+		push := normal
+		raymath.Vector2Scale(&push, depth-into)
+
+		return Contact{
+			Normal:   normal,
+			Depth:    depth,
+			Movement: raymath.Vector2Add(delta, push),
+		}, true
+	}
+
+	length := raymath.Vector2Length(delta)
+
+	if length == 0 {
+		return Contact{}, false
+	}
+
+	step := float32(math.Max(math.Min(float64(start.extent()), float64(other.extent())), 1))
+	steps := int(math.Ceil(float64(length / step)))
+
+	var lo float32
+
+	at := func(t float32) rl.Vector2 {
+		offset := delta
+		raymath.Vector2Scale(&offset, t)
+		return offset
+	}
+
+	for i := 1; i <= steps; i++ {
+		hi := float32(i) / float32(steps)
+
+		if _, _, ok := testShapes(getWorldShape(o, at(hi)), other); !ok {
+			lo = hi
+			continue
+		}
+
+		for k := 0; k < SweepBisections; k++ {
+			mid := (lo + hi) / 2
+
+			if _, _, ok := testShapes(getWorldShape(o, at(mid)), other); ok {
+				hi = mid
+			} else {
+				lo = mid
+			}
+		}
+
+		normal, depth, _ := testShapes(getWorldShape(o, at(hi)), other)
+
+		return Contact{
+			Normal:   normal,
+			Depth:    depth,
+			Time:     lo,
+			Movement: at(lo),
+		}, true
+	}
+
+	return Contact{}, false
+}
+
+// DrawCollisionShape draws the outline of the object's collision shape
+func DrawCollisionShape(o *Object, color rl.Color) {
+	s := getWorldShape(o, rl.Vector2{})
+
+	if s.isCircle {
+		rl.DrawCircleLines(int32(s.center.X), int32(s.center.Y), s.radius, color)
+		return
+	}
+
+	for i := range s.points {
+		rl.DrawLineV(s.points[i], s.points[(i+1)%len(s.points)], color)
+	}
+}
+
+// convexHull returns the convex hull of the points in clockwise order (in screen space)
+func convexHull(points []rl.Vector2) []rl.Vector2 {
+	pts := append([]rl.Vector2{}, points...)
+
+	sort.Slice(pts, func(i, j int) bool {
+		if pts[i].X != pts[j].X {
+			return pts[i].X < pts[j].X
+		}
+
+		return pts[i].Y < pts[j].Y
+	})
+
+	if len(pts) < 3 {
+		return pts
+	}
+
+	cross := func(o, a, b rl.Vector2) float32 {
+		return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+	}
+
+	hull := []rl.Vector2{}
+
+	for _, p := range pts {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+
+		hull = append(hull, p)
+	}
+
+	lower := len(hull) + 1
+
+	for i := len(pts) - 2; i >= 0; i-- {
+		p := pts[i]
+
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+
+		hull = append(hull, p)
+	}
+
+	return hull[:len(hull)-1]
+}
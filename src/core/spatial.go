@@ -0,0 +1,287 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"sort"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+)
+
+var (
+	// BroadPhaseEnabled makes collision, trigger and culling queries use the spatial index
+	BroadPhaseEnabled = true
+
+	// SpatialCellSize is the size of a spatial hash cell in pixels
+	SpatialCellSize int32 = 128
+)
+
+type spatialKey struct {
+	X, Y int32
+}
+
+// spatialEntry tracks the cells an object is stored in
+type spatialEntry struct {
+	isIndexed bool
+	order     int
+	stamp     uint32
+	minCell   spatialKey
+	maxCell   spatialKey
+}
+
+// spatialHash is a uniform grid of buckets holding objects overlapping each cell
+type spatialHash struct {
+	cellSize  int32
+	cells     map[spatialKey][]*Object
+	stamp     uint32
+	nextOrder int
+}
+
+func newSpatialHash(cellSize int32) *spatialHash {
+	return &spatialHash{
+		cellSize: cellSize,
+		cells:    make(map[spatialKey][]*Object),
+	}
+}
+
+// getObjectBounds returns the area an object occupies, including its origin and polylines
+func getObjectBounds(o *Object) rl.RectangleInt32 {
+	rec := o.GetAABB(o)
+
+	minX, minY := rec.X, rec.Y
+	maxX, maxY := rec.X+rec.Width, rec.Y+rec.Height
+
+	points := [][2]int32{
+		{int32(o.Position.X), int32(o.Position.Y)},
+		{int32(o.Position.X + float32(rec.Width)/2), int32(o.Position.Y + float32(rec.Height)/2)},
+	}
+
+	if o.Shape != nil {
+		b := GetShapeBounds(o)
+		points = append(points, [2]int32{b.X, b.Y}, [2]int32{b.X + b.Width, b.Y + b.Height})
+	}
+
+	for _, pl := range o.PolyLines {
+		for _, p := range *pl.Points {
+			points = append(points, [2]int32{int32(o.Position.X) + int32(p.X), int32(o.Position.Y) + int32(p.Y)})
+		}
+	}
+
+	for _, p := range points {
+		minX = minInt32(minX, p[0])
+		minY = minInt32(minY, p[1])
+		maxX = maxInt32(maxX, p[0])
+		maxY = maxInt32(maxY, p[1])
+	}
+
+	return rl.RectangleInt32{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+func (h *spatialHash) cellRange(rec rl.RectangleInt32) (spatialKey, spatialKey) {
+	return spatialKey{floorDiv(rec.X, h.cellSize), floorDiv(rec.Y, h.cellSize)},
+		spatialKey{floorDiv(rec.X+rec.Width, h.cellSize), floorDiv(rec.Y+rec.Height, h.cellSize)}
+}
+
+func (h *spatialHash) insert(o *Object) {
+	if !o.spatial.isIndexed {
+		o.spatial.order = h.nextOrder
+		h.nextOrder++
+	}
+
+	o.spatial.minCell, o.spatial.maxCell = h.cellRange(getObjectBounds(o))
+	o.spatial.isIndexed = true
+
+	for y := o.spatial.minCell.Y; y <= o.spatial.maxCell.Y; y++ {
+		for x := o.spatial.minCell.X; x <= o.spatial.maxCell.X; x++ {
+			k := spatialKey{x, y}
+			h.cells[k] = append(h.cells[k], o)
+		}
+	}
+}
+
+func (h *spatialHash) remove(o *Object) {
+	if !o.spatial.isIndexed {
+		return
+	}
+
+	for y := o.spatial.minCell.Y; y <= o.spatial.maxCell.Y; y++ {
+		for x := o.spatial.minCell.X; x <= o.spatial.maxCell.X; x++ {
+			k := spatialKey{x, y}
+			cell := h.cells[k]
+
+			for i, v := range cell {
+				if v == o {
+					cell[i] = cell[len(cell)-1]
+					cell = cell[:len(cell)-1]
+					break
+				}
+			}
+
+			if len(cell) == 0 {
+				delete(h.cells, k)
+			} else {
+				h.cells[k] = cell
+			}
+		}
+	}
+}
+
+// update moves the object to new cells if its bounds have changed
+func (h *spatialHash) update(o *Object) {
+	minCell, maxCell := h.cellRange(getObjectBounds(o))
+
+	if o.spatial.isIndexed && minCell == o.spatial.minCell && maxCell == o.spatial.maxCell {
+		return
+	}
+
+	h.remove(o)
+
+	order := o.spatial.order
+	h.insert(o)
+	o.spatial.order = order
+}
+
+// query returns all objects whose cells overlap the rectangle, in the order they were added to the world
+func (h *spatialHash) query(rec rl.RectangleInt32) []*Object {
+	h.stamp++
+	res := []*Object{}
+	minCell, maxCell := h.cellRange(rec)
+
+	for y := minCell.Y; y <= maxCell.Y; y++ {
+		for x := minCell.X; x <= maxCell.X; x++ {
+			for _, o := range h.cells[spatialKey{x, y}] {
+				if o.spatial.stamp == h.stamp {
+					continue
+				}
+
+				o.spatial.stamp = h.stamp
+				res = append(res, o)
+			}
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].spatial.order < res[j].spatial.order
+	})
+
+	return res
+}
+
+func (w *World) getSpatialIndex() *spatialHash {
+	if w.spatial == nil || w.spatial.cellSize != SpatialCellSize {
+		w.spatial = newSpatialHash(SpatialCellSize)
+
+		for _, o := range w.Objects {
+			w.spatial.insert(o)
+		}
+	}
+
+	return w.spatial
+}
+
+// refreshSpatialIndex re-buckets objects which have moved since the last refresh
+func (w *World) refreshSpatialIndex() {
+	spatialProfiler.StartInvocation()
+	h := w.getSpatialIndex()
+
+	for _, o := range w.Objects {
+		h.update(o)
+	}
+	spatialProfiler.StopInvocation()
+}
+
+// QueryObjects returns objects potentially overlapping the rectangle.
+// It falls back to all world objects when the broad phase is disabled.
+func (w *World) QueryObjects(rec rl.RectangleInt32) []*Object {
+	if !BroadPhaseEnabled {
+		return w.Objects
+	}
+
+	return w.getSpatialIndex().query(rec)
+}
+
+// sweepRectangle returns the area covered by a rectangle moving by the delta
+func sweepRectangle(rec rl.RectangleInt32, deltaX, deltaY int32) rl.RectangleInt32 {
+	if deltaX < 0 {
+		rec.X += deltaX
+		rec.Width -= deltaX
+	} else {
+		rec.Width += deltaX
+	}
+
+	if deltaY < 0 {
+		rec.Y += deltaY
+		rec.Height -= deltaY
+	} else {
+		rec.Height += deltaY
+	}
+
+	return rec
+}
+
+func expandRectangle(rec rl.RectangleInt32, margin int32) rl.RectangleInt32 {
+	return rl.RectangleInt32{
+		X:      rec.X - margin,
+		Y:      rec.Y - margin,
+		Width:  rec.Width + margin*2,
+		Height: rec.Height + margin*2,
+	}
+}
+
+func rectanglesOverlap(a, b rl.RectangleInt32) bool {
+	return a.X <= b.X+b.Width && b.X <= a.X+a.Width && a.Y <= b.Y+b.Height && b.Y <= a.Y+a.Height
+}
+
+func floorDiv(a, b int32) int32 {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+
+	return a / b
+}
+
+func minInt32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func maxInt32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,84 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"math/rand"
+	"testing"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+)
+
+const (
+	benchmarkObjectCount = 4000
+	benchmarkWorldSize   = 8192
+)
+
+// benchmarkHits keeps the compiler from dropping the overlap tests
+var benchmarkHits int
+
+// newBenchmarkWorld scatters objects of various sizes over a large map, the seed keeps both benchmarks on the same set
+func newBenchmarkWorld() *World {
+	w := &World{}
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < benchmarkObjectCount; i++ {
+		w.Objects = append(w.Objects, &Object{
+			GID:      i,
+			Position: rl.NewVector2(float32(r.Intn(benchmarkWorldSize)), float32(r.Intn(benchmarkWorldSize))),
+			Size:     []int32{int32(8 + r.Intn(56)), int32(8 + r.Intn(56))},
+			GetAABB:  GetSolidAABB,
+		})
+	}
+
+	return w
+}
+
+// BenchmarkSpatialQuery looks up each object's neighbours through the spatial hash
+func BenchmarkSpatialQuery(b *testing.B) {
+	w := newBenchmarkWorld()
+	h := w.getSpatialIndex()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		o := w.Objects[i%len(w.Objects)]
+		rec := expandRectangle(getObjectBounds(o), 1)
+
+		for _, c := range h.query(rec) {
+			if c != o && rectanglesOverlap(rec, getObjectBounds(c)) {
+				benchmarkHits++
+			}
+		}
+	}
+}
+
+// BenchmarkLinearScan looks up each object's neighbours by testing every object in the world
+func BenchmarkLinearScan(b *testing.B) {
+	w := newBenchmarkWorld()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		o := w.Objects[i%len(w.Objects)]
+		rec := expandRectangle(getObjectBounds(o), 1)
+
+		for _, c := range w.Objects {
+			if c != o && rectanglesOverlap(rec, getObjectBounds(c)) {
+				benchmarkHits++
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"sort"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/raylib-go/raymath"
+)
+
+var (
+	// SplineSegmentSamples is the number of samples a spline segment is approximated with
+	SplineSegmentSamples = 16
+)
+
+// Spline is a Catmull-Rom spline passing through all of its points, parametrized by its length
+type Spline struct {
+	Points []rl.Vector2
+
+	samples []rl.Vector2
+	lengths []float32
+}
+
+// NewSpline creates a spline passing through the points
+func NewSpline(points []rl.Vector2) *Spline {
+	s := &Spline{Points: points}
+	s.sample()
+
+	return s
+}
+
+// Length returns the approximate length of the spline
+func (s *Spline) Length() float32 {
+	if len(s.lengths) == 0 {
+		return 0
+	}
+
+	return s.lengths[len(s.lengths)-1]
+}
+
+// PointAt returns the point at the relative distance along the spline, t ranges from 0 to 1
+func (s *Spline) PointAt(t float32) rl.Vector2 {
+	if len(s.samples) == 0 {
+		return rl.Vector2{}
+	}
+
+	if t <= 0 || len(s.samples) == 1 {
+		return s.samples[0]
+	}
+
+	if t >= 1 {
+		return s.samples[len(s.samples)-1]
+	}
+
+	dist := t * s.Length()
+	idx := sort.Search(len(s.lengths), func(i int) bool {
+		return s.lengths[i] >= dist
+	})
+
+	if idx == 0 {
+		return s.samples[0]
+	}
+
+	span := s.lengths[idx] - s.lengths[idx-1]
+
+	if span == 0 {
+		return s.samples[idx]
+	}
+
+	return Vector2Lerp(s.samples[idx-1], s.samples[idx], (dist-s.lengths[idx-1])/span)
+}
+
+// Draw draws the sampled spline
+func (s *Spline) Draw(color rl.Color) {
+	for i := 1; i < len(s.samples); i++ {
+		rl.DrawLineV(s.samples[i-1], s.samples[i], color)
+	}
+
+	for _, p := range s.Points {
+		rl.DrawCircleV(p, 2, color)
+	}
+}
+
+func (s *Spline) sample() {
+	s.samples = []rl.Vector2{}
+	s.lengths = []float32{}
+
+	if len(s.Points) == 0 {
+		return
+	}
+
+	s.addSample(s.Points[0])
+
+	for i := 0; i < len(s.Points)-1; i++ {
+		p1 := s.Points[i]
+		p2 := s.Points[i+1]
+		p0, p3 := p1, p2
+
+		if i > 0 {
+			p0 = s.Points[i-1]
+		}
+
+		if i+2 < len(s.Points) {
+			p3 = s.Points[i+2]
+		}
+
+		for j := 1; j <= SplineSegmentSamples; j++ {
+			s.addSample(catmullRom(p0, p1, p2, p3, float32(j)/float32(SplineSegmentSamples)))
+		}
+	}
+}
+
+func (s *Spline) addSample(p rl.Vector2) {
+	var length float32
+
+	if n := len(s.samples); n > 0 {
+		length = s.lengths[n-1] + raymath.Vector2Distance(p, s.samples[n-1])
+	}
+
+	s.samples = append(s.samples, p)
+	s.lengths = append(s.lengths, length)
+}
+
+// catmullRom interpolates between p1 and p2, p0 and p3 shape the curve
+func catmullRom(p0, p1, p2, p3 rl.Vector2, t float32) rl.Vector2 {
+	t2 := t * t
+	t3 := t2 * t
+
+	interp := func(a, b, c, d float32) float32 {
+		return 0.5 * (2*b + (c-a)*t + (2*a-5*b+4*c-d)*t2 + (3*b-a-3*c+d)*t3)
+	}
+
+	return rl.Vector2{
+		X: interp(p0.X, p1.X, p2.X, p3.X),
+		Y: interp(p0.Y, p1.Y, p2.Y, p3.Y),
+	}
+}
@@ -0,0 +1,104 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/rurik/src/system"
+)
+
+// newSpriteComponent draws an Aseprite frame or a texture stretched over the object's size.
+// Sprites share the animation of their proxy
+func newSpriteComponent(o *Object, c *Component) {
+	if o.Size == nil {
+		o.Size = []int32{int32(o.Meta.Width), int32(o.Meta.Height)}
+	}
+
+	c.Finish = func(o *Object) {
+		if o.FileName == "" {
+			return
+		}
+
+		o.Texture = system.GetTexture("gfx/" + o.FileName + ".png")
+		o.NormalMap = system.GetNormalMap("gfx/" + o.FileName + ".png")
+
+		if o.Proxy != nil && o.Proxy.Ase != nil {
+			o.Ase = o.Proxy.Ase
+		} else if system.FindAsset("gfx/"+o.FileName+".json") != nil {
+			aseData := system.GetAnimData("gfx/" + o.FileName + ".json")
+			o.Ase = &aseData
+		}
+	}
+
+	o.GetAABB = GetSpriteAABB
+	c.Batched = true
+
+	c.Draw = func(o *Object) {
+		if o.Ase != nil {
+			if DebugMode && o.DebugVisible {
+				FlushSpriteBatch()
+				c := GetSpriteAABB(o)
+				rl.DrawRectangleLinesEx(c.ToFloat32(), 1, rl.Blue)
+				DrawTextCentered(o.Name, c.X+c.Width/2, c.Y+c.Height+2, 1, rl.White)
+			}
+
+			drawSpriteTexture(o, o.Texture, rl.White)
+			return
+		}
+
+		dest := rl.NewRectangle(o.Position.X, o.Position.Y, float32(o.Size[0]), float32(o.Size[1]))
+
+		if o.Texture != nil {
+			drawSpriteTexture(o, o.Texture, rl.White)
+			return
+		}
+
+		if dest.Width == 0 || dest.Height == 0 {
+			return
+		}
+
+		// NOTE: placeholder for sprites without a texture
+		color := o.Color
+
+		if color == rl.Blank {
+			color = rl.Brown
+		}
+
+		FlushSpriteBatch()
+		rl.DrawRectangleRec(dest, color)
+		rl.DrawRectangleLinesEx(dest, 1, rl.DarkBrown)
+	}
+
+	c.DrawNormals = func(o *Object) {
+		if o.Texture != nil {
+			tex, tint := getNormalTexture(o.Texture, o.NormalMap)
+			drawSpriteTexture(o, tex, tint)
+		}
+	}
+}
+
+// drawSpriteTexture draws the current Aseprite frame or the whole texture stretched over the object's size
+func drawSpriteTexture(o *Object, tex *rl.Texture2D, tint rl.Color) {
+	if o.Ase != nil {
+		DrawSprite(*tex, GetSpriteRectangle(o), GetSpriteOrigin(o), rl.Vector2{}, o.Rotation, tint)
+		return
+	}
+
+	dest := rl.NewRectangle(o.Position.X, o.Position.Y, float32(o.Size[0]), float32(o.Size[1]))
+	source := rl.NewRectangle(0, 0, float32(tex.Width), float32(tex.Height))
+	DrawSprite(*tex, source, dest, rl.Vector2{}, o.Rotation, tint)
+}
@@ -0,0 +1,177 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"math"
+	"math/rand"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/raylib-go/raymath"
+)
+
+var (
+	// SteeringArriveRadius is the distance at which a waypoint counts as reached
+	SteeringArriveRadius float32 = 4
+
+	// SteeringWanderJitter is the maximum change of the wander heading per second, in radians
+	SteeringWanderJitter float32 = 3
+)
+
+// SteerSeek returns the velocity moving the object straight towards the target
+func SteerSeek(o *Object, target rl.Vector2, speed float32) rl.Vector2 {
+	dir := raymath.Vector2Subtract(target, o.Position)
+
+	if dir.X == 0 && dir.Y == 0 {
+		return dir
+	}
+
+	raymath.Vector2Normalize(&dir)
+	raymath.Vector2Scale(&dir, speed)
+	return dir
+}
+
+// SteerFlee returns the velocity moving the object straight away from the threat
+func SteerFlee(o *Object, threat rl.Vector2, speed float32) rl.Vector2 {
+	velocity := SteerSeek(o, threat, speed)
+	raymath.Vector2Negate(&velocity)
+	return velocity
+}
+
+// SteerArrive returns the velocity moving the object towards the target, slowing down within the radius
+func SteerArrive(o *Object, target rl.Vector2, speed, slowRadius float32) rl.Vector2 {
+	offset := raymath.Vector2Subtract(target, o.Position)
+	dist := raymath.Vector2Length(offset)
+
+	if dist <= SteeringArriveRadius {
+		return rl.Vector2{}
+	}
+
+	if dist < slowRadius {
+		speed *= dist / slowRadius
+	}
+
+	raymath.Vector2Scale(&offset, speed/dist)
+	return offset
+}
+
+// SteerWander returns the velocity of a random walk, the heading is kept between calls
+func SteerWander(heading *float32, speed, dt float32) rl.Vector2 {
+	*heading += (rand.Float32()*2 - 1) * SteeringWanderJitter * dt
+
+	return rl.Vector2{
+		X: float32(math.Cos(float64(*heading))) * speed,
+		Y: float32(math.Sin(float64(*heading))) * speed,
+	}
+}
+
+// SteerPatrol returns the velocity moving the object along the waypoints,
+// the index advances to the next waypoint and wraps around at the end
+func SteerPatrol(o *Object, points []rl.Vector2, index *int, speed float32) rl.Vector2 {
+	if len(points) == 0 {
+		return rl.Vector2{}
+	}
+
+	*index %= len(points)
+
+	if raymath.Vector2Distance(points[*index], o.Position) <= SteeringArriveRadius {
+		*index = (*index + 1) % len(points)
+	}
+
+	return SteerSeek(o, points[*index], speed)
+}
+
+// SteerFollow returns the velocity keeping the object at the distance behind the leader
+func SteerFollow(o, leader *Object, speed, distance float32) rl.Vector2 {
+	behind := leader.Facing
+
+	if behind.X != 0 || behind.Y != 0 {
+		raymath.Vector2Normalize(&behind)
+		raymath.Vector2Scale(&behind, distance)
+	}
+
+	behind = raymath.Vector2Subtract(leader.Position, behind)
+
+	if raymath.Vector2Distance(leader.Position, o.Position) <= distance {
+		return rl.Vector2{}
+	}
+
+	return SteerArrive(o, behind, speed, distance)
+}
+
+// SteerPath returns the velocity following the waypoints, reached waypoints are removed from the path
+func SteerPath(o *Object, path *[]rl.Vector2, speed, slowRadius float32) rl.Vector2 {
+	for len(*path) > 1 && raymath.Vector2Distance((*path)[0], o.Position) <= SteeringArriveRadius {
+		*path = (*path)[1:]
+	}
+
+	if len(*path) == 0 {
+		return rl.Vector2{}
+	}
+
+	if len(*path) == 1 {
+		return SteerArrive(o, (*path)[0], speed, slowRadius)
+	}
+
+	return SteerSeek(o, (*path)[0], speed)
+}
+
+// GetPolyLinePoints returns the world space points of the object's polylines
+func GetPolyLinePoints(o *Object) []rl.Vector2 {
+	points := []rl.Vector2{}
+
+	for _, pl := range o.PolyLines {
+		for _, p := range *pl.Points {
+			points = append(points, rl.Vector2{X: o.Position.X + float32(p.X), Y: o.Position.Y + float32(p.Y)})
+		}
+	}
+
+	return points
+}
+
+// MoveWithCollision moves the object by the velocity, sliding along surfaces it runs into
+func (o *Object) MoveWithCollision(velocity rl.Vector2, dt float32) {
+	delta := velocity
+	raymath.Vector2Scale(&delta, dt)
+	o.Movement = delta
+
+	for i := 0; i < PhysicsIterations; i++ {
+		contact, ok := CheckForContact(o, delta.X, delta.Y)
+
+		if !ok {
+			o.SetPosition(o.Position.X+delta.X, o.Position.Y+delta.Y)
+			return
+		}
+
+		o.SetPosition(o.Position.X+contact.Movement.X, o.Position.Y+contact.Movement.Y)
+
+		if contact.Time == 0 {
+			return
+		}
+
+		// NOTE: the rest of the movement slides along the surface
+		rest := delta
+		raymath.Vector2Scale(&rest, 1-contact.Time)
+		slide := contact.Normal
+		raymath.Vector2Scale(&slide, raymath.Vector2DotProduct(rest, contact.Normal))
+		delta = raymath.Vector2Subtract(rest, slide)
+
+		if raymath.Vector2Length(delta) < 0.01 {
+			return
+		}
+	}
+}
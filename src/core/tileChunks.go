@@ -0,0 +1,408 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+
+	tiled "github.com/zaklaus/go-tiled"
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/rurik/src/system"
+)
+
+var (
+	// TileChunksEnabled toggles drawing of the tile layers from pre-baked chunks
+	TileChunksEnabled = true
+
+	// TileChunkSize is the width and height of a chunk in tiles
+	TileChunkSize = 16
+
+	// TileChunksDrawn and TileChunksBaked are the statistics of the last frame
+	TileChunksDrawn int
+	TileChunksBaked int
+
+	tileChunksDrawn int
+	tileChunksBaked int
+
+	tileCoverageProgram system.Program
+	tileColorProgram    system.Program
+)
+
+// tileChunkPass selects the layers baked into the chunks and whether they hold the normal maps
+type tileChunkPass struct {
+	overlays bool
+	normals  bool
+}
+
+// tileChunkGrid holds the baked chunks of a pass
+type tileChunkGrid struct {
+	columns int
+	rows    int
+	chunks  []tileChunk
+}
+
+// tileChunk is a block of tiles rendered into a texture, it is baked again once its tiles change.
+// The target holds the premultiplied color, the coverage holds how much of the background shows through
+type tileChunk struct {
+	target   system.RenderTarget
+	coverage system.RenderTarget
+	isBaked  bool
+	isEmpty  bool
+}
+
+// SetTile replaces the tile at the given position of the layer, the global tile ID 0 clears it
+func (m *Map) SetTile(layerName string, x, y, tileID int) error {
+	if x < 0 || y < 0 || x >= m.tilemap.Width || y >= m.tilemap.Height {
+		return fmt.Errorf("tile %d, %d is out of the map's bounds", x, y)
+	}
+
+	for _, layer := range m.tilemap.Layers {
+		if layer.Name != layerName {
+			continue
+		}
+
+		tile := &tiled.LayerTile{}
+
+		if tileID > 0 {
+			for _, v := range m.tilemap.Tilesets {
+				if uint32(tileID) >= v.FirstGID {
+					tile.Tileset = v
+					tile.ID = uint32(tileID) - v.FirstGID
+				}
+			}
+		}
+
+		layer.Tiles[y*m.tilemap.Width+x] = tile
+		m.invalidateTileChunk(x/TileChunkSize, y/TileChunkSize)
+
+		// NOTE: collisions are rebuilt once per update, so many tiles can be changed at once
+		m.areTileCollisionsDirty = true
+		return nil
+	}
+
+	return fmt.Errorf("layer '%s' could not be found", layerName)
+}
+
+// InvalidateTiles bakes all chunks and rebuilds the tile collisions again, it is needed after changing the layers directly
+func (m *Map) InvalidateTiles() {
+	m.areTileCollisionsDirty = true
+
+	for _, grid := range m.tileChunks {
+		for i := range grid.chunks {
+			grid.chunks[i].isBaked = false
+		}
+	}
+}
+
+func (m *Map) invalidateTileChunk(cx, cy int) {
+	for _, grid := range m.tileChunks {
+		grid.chunks[cy*grid.columns+cx].isBaked = false
+	}
+}
+
+// getTileChunks returns the pass's chunks, creating them on the first use
+func (m *Map) getTileChunks(pass tileChunkPass) *tileChunkGrid {
+	if m.tileChunks == nil {
+		m.tileChunks = make(map[tileChunkPass]*tileChunkGrid)
+	}
+
+	grid, ok := m.tileChunks[pass]
+
+	if !ok {
+		grid = &tileChunkGrid{
+			columns: (m.tilemap.Width + TileChunkSize - 1) / TileChunkSize,
+			rows:    (m.tilemap.Height + TileChunkSize - 1) / TileChunkSize,
+		}
+
+		grid.chunks = make([]tileChunk, grid.columns*grid.rows)
+		m.tileChunks[pass] = grid
+	}
+
+	return grid
+}
+
+// getTileChunkRange returns the chunks within the camera's view, or all of them when not culled
+func (m *Map) getTileChunkRange(grid *tileChunkGrid, culled bool) (x0, y0, x1, y1 int) {
+	x1, y1 = grid.columns-1, grid.rows-1
+
+	if !culled || MainCamera == nil {
+		return
+	}
+
+	view := getFrustum()
+	chunkW := float32(TileChunkSize * m.tilemap.TileWidth)
+	chunkH := float32(TileChunkSize * m.tilemap.TileHeight)
+
+	x0 = maxInt(0, int(view.X/chunkW))
+	y0 = maxInt(0, int(view.Y/chunkH))
+	x1 = minInt(x1, int((view.X+view.Width)/chunkW))
+	y1 = minInt(y1, int((view.Y+view.Height)/chunkH))
+	return
+}
+
+// bakeTileChunks renders the chunks in the main camera's view which are not baked yet,
+// it has to run before the world is drawn, since render textures can't be nested
+func (m *Map) bakeTileChunks() {
+	if !TileChunksEnabled {
+		return
+	}
+
+	passes := []tileChunkPass{{false, false}, {true, false}}
+
+	if NormalMappingEnabled && m.hasTileNormalMaps() {
+		passes = append(passes, tileChunkPass{false, true}, tileChunkPass{true, true})
+	}
+
+	for _, pass := range passes {
+		grid := m.getTileChunks(pass)
+		x0, y0, x1, y1 := m.getTileChunkRange(grid, true)
+
+		for cy := y0; cy <= y1; cy++ {
+			for cx := x0; cx <= x1; cx++ {
+				if !grid.chunks[cy*grid.columns+cx].isBaked {
+					m.bakeTileChunk(&grid.chunks[cy*grid.columns+cx], pass, cx, cy)
+				}
+			}
+		}
+	}
+}
+
+func (m *Map) bakeTileChunk(chunk *tileChunk, pass tileChunkPass, cx, cy int) {
+	chunk.isBaked = true
+	chunk.isEmpty = !m.hasChunkTiles(pass, cx, cy)
+
+	if chunk.isEmpty {
+		return
+	}
+
+	if chunk.target.ID == 0 {
+		chunk.target = system.CreateRenderTarget(
+			int32(TileChunkSize*m.tilemap.TileWidth),
+			int32(TileChunkSize*m.tilemap.TileHeight),
+		)
+
+		chunk.coverage = system.CreateRenderTarget(
+			int32(TileChunkSize*m.tilemap.TileWidth),
+			int32(TileChunkSize*m.tilemap.TileHeight),
+		)
+	}
+
+	if tileCoverageProgram.Shader.ID == 0 {
+		tileCoverageProgram = system.NewProgramFromCode("", tileCoverageProgramSrcCode)
+	}
+
+	origin := rl.Vector2{
+		X: float32(cx * TileChunkSize * m.tilemap.TileWidth),
+		Y: float32(cy * TileChunkSize * m.tilemap.TileHeight),
+	}
+
+	// NOTE: alpha blending over a blank target leaves the color premultiplied,
+	// but multiplies the alpha channel twice, so the coverage is baked on its own
+	rl.BeginTextureMode(chunk.target)
+	{
+		rl.ClearBackground(rl.Blank)
+		rl.BeginMode2D(rl.NewCamera2D(rl.Vector2{}, origin, 0, 1))
+		m.drawChunkTiles(pass, cx, cy, false)
+		rl.EndMode2D()
+	}
+	rl.EndTextureMode()
+
+	rl.BeginTextureMode(chunk.coverage)
+	{
+		rl.ClearBackground(rl.White)
+		rl.BeginMode2D(rl.NewCamera2D(rl.Vector2{}, origin, 0, 1))
+		rl.BeginShaderMode(tileCoverageProgram.Shader)
+		rl.BeginBlendMode(rl.BlendMultiplied)
+		m.drawChunkTiles(pass, cx, cy, false)
+		rl.EndBlendMode()
+		rl.EndShaderMode()
+		rl.EndMode2D()
+	}
+	rl.EndTextureMode()
+
+	tileChunksBaked++
+}
+
+// drawTileChunks draws the baked chunks in view, chunks which are not baked yet get their tiles drawn one by one.
+// Baked chunks are composited as premultiplied alpha, first the background is darkened by the coverage,
+// then the color is added on top
+func (m *Map) drawTileChunks(pass tileChunkPass) {
+	grid := m.getTileChunks(pass)
+	x0, y0, x1, y1 := m.getTileChunkRange(grid, cullingEnabled)
+	baked := []int{}
+
+	for cy := y0; cy <= y1; cy++ {
+		for cx := x0; cx <= x1; cx++ {
+			chunk := &grid.chunks[cy*grid.columns+cx]
+
+			if !chunk.isBaked {
+				m.drawChunkTiles(pass, cx, cy, cullingEnabled)
+				continue
+			}
+
+			if !chunk.isEmpty {
+				baked = append(baked, cy*grid.columns+cx)
+			}
+		}
+	}
+
+	if len(baked) == 0 {
+		return
+	}
+
+	if tileColorProgram.Shader.ID == 0 {
+		tileColorProgram = system.NewProgramFromCode("", tileColorProgramSrcCode)
+	}
+
+	rl.BeginBlendMode(rl.BlendMultiplied)
+	for _, i := range baked {
+		m.drawTileChunk(grid.chunks[i].coverage, i%grid.columns, i/grid.columns)
+	}
+	rl.EndBlendMode()
+
+	rl.BeginShaderMode(tileColorProgram.Shader)
+	rl.BeginBlendMode(rl.BlendAdditive)
+	for _, i := range baked {
+		m.drawTileChunk(grid.chunks[i].target, i%grid.columns, i/grid.columns)
+	}
+	rl.EndBlendMode()
+	rl.EndShaderMode()
+
+	tileChunksDrawn += len(baked)
+}
+
+// drawTileChunk draws the chunk's render texture at the chunk's position
+func (m *Map) drawTileChunk(target system.RenderTarget, cx, cy int) {
+	chunkW := float32(TileChunkSize * m.tilemap.TileWidth)
+	chunkH := float32(TileChunkSize * m.tilemap.TileHeight)
+
+	// NOTE: the render texture is upside down, negative height keeps it as it was drawn
+	rl.DrawTexturePro(
+		target.Texture,
+		rl.NewRectangle(0, 0, chunkW, -chunkH),
+		rl.NewRectangle(float32(cx)*chunkW, float32(cy)*chunkH, chunkW, chunkH),
+		rl.Vector2{},
+		0,
+		rl.White,
+	)
+}
+
+// drawChunkTiles draws the tiles of the chunk's layers in order
+func (m *Map) drawChunkTiles(pass tileChunkPass, cx, cy int, culled bool) {
+	x0, y0 := cx*TileChunkSize, cy*TileChunkSize
+	x1 := minInt(x0+TileChunkSize, m.tilemap.Width)
+	y1 := minInt(y0+TileChunkSize, m.tilemap.Height)
+
+	for _, layer := range m.tilemap.Layers {
+		if !isTileLayerDrawn(layer, pass.overlays) {
+			continue
+		}
+
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				m.drawLayerTile(layer.Tiles[y*m.tilemap.Width+x], y*m.tilemap.Width+x, pass.normals, culled)
+			}
+		}
+	}
+}
+
+func (m *Map) hasChunkTiles(pass tileChunkPass, cx, cy int) bool {
+	x0, y0 := cx*TileChunkSize, cy*TileChunkSize
+	x1 := minInt(x0+TileChunkSize, m.tilemap.Width)
+	y1 := minInt(y0+TileChunkSize, m.tilemap.Height)
+
+	for _, layer := range m.tilemap.Layers {
+		if !isTileLayerDrawn(layer, pass.overlays) {
+			continue
+		}
+
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				if tile := layer.Tiles[y*m.tilemap.Width+x]; !tile.IsNil() && tile.Tileset != nil {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// unloadTileChunks releases the chunks' render textures
+func (m *Map) unloadTileChunks() {
+	for _, grid := range m.tileChunks {
+		for _, v := range grid.chunks {
+			if v.target.ID != 0 {
+				rl.UnloadRenderTexture(v.target)
+				rl.UnloadRenderTexture(v.coverage)
+			}
+		}
+	}
+
+	m.tileChunks = nil
+}
+
+// resetTileChunkStats publishes the statistics of the frame
+func resetTileChunkStats() {
+	TileChunksDrawn, TileChunksBaked = tileChunksDrawn, tileChunksBaked
+	tileChunksDrawn, tileChunksBaked = 0, 0
+}
+
+/* Built-in shaders */
+
+// NOTE: every tile scales the coverage by how much of it is see-through
+const tileCoverageProgramSrcCode = `
+#version 330
+
+// Input vertex attributes (from vertex shader)
+in vec2 fragTexCoord;
+in vec4 fragColor;
+
+// Input uniform values
+uniform sampler2D texture0;
+uniform vec4 colDiffuse;
+
+// Output fragment color
+out vec4 finalColor;
+
+void main()
+{
+    float alpha = texture(texture0, fragTexCoord).a*fragColor.a*colDiffuse.a;
+    finalColor = vec4(vec3(1.0 - alpha), 1.0);
+}
+`
+
+// NOTE: the color is premultiplied already, it is added as it is
+const tileColorProgramSrcCode = `
+#version 330
+
+// Input vertex attributes (from vertex shader)
+in vec2 fragTexCoord;
+in vec4 fragColor;
+
+// Input uniform values
+uniform sampler2D texture0;
+uniform vec4 colDiffuse;
+
+// Output fragment color
+out vec4 finalColor;
+
+void main()
+{
+    finalColor = vec4(texture(texture0, fragTexCoord).rgb, 1.0);
+}
+`
@@ -0,0 +1,336 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	tiled "github.com/zaklaus/go-tiled"
+	rl "github.com/zaklaus/raylib-go/raylib"
+)
+
+// tilesetTileData holds per-tile data defined in the tileset editor
+type tilesetTileData struct {
+	ID     int32              `xml:"id,attr"`
+	Shapes []tilesetTileShape `xml:"objectgroup>object"`
+}
+
+// tilesetTileShape is a tile collision shape, rectangles have no points
+type tilesetTileShape struct {
+	X        float32             `xml:"x,attr"`
+	Y        float32             `xml:"y,attr"`
+	Width    float32             `xml:"width,attr"`
+	Height   float32             `xml:"height,attr"`
+	Polygon  *tilesetShapePoints `xml:"polygon"`
+	PolyLine *tilesetShapePoints `xml:"polyline"`
+}
+
+type tilesetShapePoints struct {
+	Points string `xml:"points,attr"`
+}
+
+type tileOrientation struct {
+	HorizontalFlip bool
+	VerticalFlip   bool
+	DiagonalFlip   bool
+}
+
+// getTileShapes returns collision shapes of a tile
+func (t *tilesetData) getTileShapes(tileID int32) []tilesetTileShape {
+	if t.tileShapes == nil {
+		t.tileShapes = make(map[int32][]tilesetTileShape)
+
+		for _, v := range t.Tiles {
+			if len(v.Shapes) > 0 {
+				t.tileShapes[v.ID] = v.Shapes
+			}
+		}
+	}
+
+	return t.tileShapes[tileID]
+}
+
+// transform maps a point inside of a tile to its flipped position
+// NOTE: Tiled swaps the axes first, then applies the horizontal and vertical flips
+func (f tileOrientation) transform(x, y, width, height float32) (float32, float32) {
+	if f.DiagonalFlip {
+		x, y = y, x
+		width, height = height, width
+	}
+
+	if f.HorizontalFlip {
+		x = width - x
+	}
+
+	if f.VerticalFlip {
+		y = height - y
+	}
+
+	return x, y
+}
+
+// CreateTileCollisions builds static collision bodies from tile collision shapes of all tile layers.
+// Adjacent rectangles are merged into larger bodies, polygons become solid shapes and polylines slopes.
+// Set the layer property "col" to "0" to disable collision of a layer,
+// "colType" overrides the collision layers of the spawned bodies and "colMask" the layers they collide with.
+func (m *Map) CreateTileCollisions(w *World) {
+	tileW := float32(m.tilemap.TileWidth)
+	tileH := float32(m.tilemap.TileHeight)
+
+	for layerIndex, layer := range m.tilemap.Layers {
+		if layer.Properties.GetString("col") == "0" {
+			continue
+		}
+
+		rects := []rl.RectangleInt32{}
+		count := 0
+
+		for tileIndex, tile := range layer.Tiles {
+			if tile.IsNil() || tile.Tileset == nil {
+				continue
+			}
+
+			ts := m.loadMapTilesetData(tile.Tileset.Source)
+			shapes := ts.getTileShapes(int32(tile.ID))
+
+			if len(shapes) == 0 {
+				continue
+			}
+
+			flip := tileOrientation{
+				HorizontalFlip: tile.HorizontalFlip,
+				VerticalFlip:   tile.VerticalFlip,
+				DiagonalFlip:   tile.DiagonalFlip,
+			}
+
+			tileX, tileY := m.GetWorldPositionFromID(uint32(tileIndex), tileW, tileH)
+			shapeW := float32(ts.TileWidth)
+			shapeH := float32(ts.TileHeight)
+
+			for _, s := range shapes {
+				if s.Polygon == nil && s.PolyLine == nil {
+					x0, y0 := flip.transform(s.X, s.Y, shapeW, shapeH)
+					x1, y1 := flip.transform(s.X+s.Width, s.Y+s.Height, shapeW, shapeH)
+
+					rects = append(rects, rl.RectangleInt32{
+						X:      RoundFloatToInt32(tileX + float32(math.Min(float64(x0), float64(x1)))),
+						Y:      RoundFloatToInt32(tileY + float32(math.Min(float64(y0), float64(y1)))),
+						Width:  RoundFloatToInt32(float32(math.Abs(float64(x1 - x0)))),
+						Height: RoundFloatToInt32(float32(math.Abs(float64(y1 - y0)))),
+					})
+					continue
+				}
+
+				points := s.PolyLine
+				isClosed := false
+
+				if s.Polygon != nil {
+					points = s.Polygon
+					isClosed = true
+				}
+
+				pts := parseShapePoints(points.Points, isClosed)
+
+				for k, p := range pts {
+					px, py := flip.transform(s.X+float32(p.X), s.Y+float32(p.Y), shapeW, shapeH)
+					pts[k] = &tiled.Point{X: float64(px), Y: float64(py)}
+				}
+
+				col := &tiled.Object{
+					X:      float64(tileX),
+					Y:      float64(tileY),
+					Width:  float64(tileW),
+					Height: float64(tileH),
+				}
+
+				// NOTE: slopes only push objects up, so closed polygons have to be solid to act as walls
+				if isClosed {
+					col.Polygons = []*tiled.Polygon{{Points: &pts}}
+				} else {
+					col.PolyLines = []*tiled.PolyLine{{Points: &pts}}
+				}
+
+				m.spawnTileCollision(w, layer, fmt.Sprintf("tilecol_%d_%d", layerIndex, count), col)
+				count++
+			}
+		}
+
+		for _, r := range mergeTileRectangles(rects) {
+			m.spawnTileCollision(w, layer, fmt.Sprintf("tilecol_%d_%d", layerIndex, count), &tiled.Object{
+				X:      float64(r.X),
+				Y:      float64(r.Y),
+				Width:  float64(r.Width),
+				Height: float64(r.Height),
+			})
+			count++
+		}
+	}
+}
+
+func (m *Map) spawnTileCollision(w *World, layer *tiled.Layer, name string, col *tiled.Object) {
+	col.Name = name
+	col.Type = "col"
+	obj := w.spawnObject(col)
+
+	if obj == nil {
+		return
+	}
+
+	if colType := layer.Properties.GetString("colType"); colType != "" {
+		obj.CollisionType, obj.CollisionLayers = parseCollisionLayers(colType)
+		obj.IsCollidable = obj.CollisionType != CollisionNone
+	}
+
+	obj.CollisionMask = RetrieveCollisionMask(splitCollisionTypeNames(layer.Properties.GetString("colMask")))
+	obj.DebugVisible = layer.Properties.GetString("dbgShow") == "1"
+
+	// NOTE: tile collisions are rebuilt from the map on load
+	obj.IsPersistent = false
+
+	w.AddObject(obj)
+	m.tileCollisions = append(m.tileCollisions, obj)
+}
+
+// rebuildTileCollisions replaces the tile collision bodies after the tiles have changed,
+// the navigation grid is built again, as it bakes the static collision in
+func (m *Map) rebuildTileCollisions() {
+	w := m.World
+	m.areTileCollisionsDirty = false
+
+	if w == nil {
+		return
+	}
+
+	isTileCollision := make(map[*Object]bool)
+
+	for _, o := range m.tileCollisions {
+		isTileCollision[o] = true
+
+		if w.spatial != nil {
+			w.spatial.remove(o)
+		}
+	}
+
+	objects := make([]*Object, 0, len(w.Objects))
+
+	for _, o := range w.Objects {
+		if !isTileCollision[o] {
+			objects = append(objects, o)
+		}
+	}
+
+	w.Objects = objects
+	m.tileCollisions = nil
+	m.CreateTileCollisions(w)
+
+	for _, o := range m.tileCollisions {
+		o.Finish(o)
+		o.finishComponents()
+	}
+
+	w.refreshSpatialIndex()
+	m.buildNavGrid(w)
+}
+
+// mergeTileRectangles joins touching rectangles into rows first, then stacks rows of the same span
+func mergeTileRectangles(rects []rl.RectangleInt32) []rl.RectangleInt32 {
+	sort.Slice(rects, func(i, j int) bool {
+		a, b := rects[i], rects[j]
+
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+
+		if a.Height != b.Height {
+			return a.Height < b.Height
+		}
+
+		return a.X < b.X
+	})
+
+	rows := []rl.RectangleInt32{}
+
+	for _, r := range rects {
+		if n := len(rows); n > 0 {
+			last := &rows[n-1]
+
+			if last.Y == r.Y && last.Height == r.Height && r.X <= last.X+last.Width {
+				last.Width = maxInt32(last.Width, r.X+r.Width-last.X)
+				continue
+			}
+		}
+
+		rows = append(rows, r)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+
+		if a.X != b.X {
+			return a.X < b.X
+		}
+
+		if a.Width != b.Width {
+			return a.Width < b.Width
+		}
+
+		return a.Y < b.Y
+	})
+
+	merged := []rl.RectangleInt32{}
+
+	for _, r := range rows {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+
+			if last.X == r.X && last.Width == r.Width && r.Y <= last.Y+last.Height {
+				last.Height = maxInt32(last.Height, r.Y+r.Height-last.Y)
+				continue
+			}
+		}
+
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+func parseShapePoints(data string, isClosed bool) tiled.Points {
+	pts := tiled.Points{}
+
+	for _, v := range strings.Fields(data) {
+		xy := strings.Split(v, ",")
+
+		if len(xy) != 2 {
+			continue
+		}
+
+		x, _ := strconv.ParseFloat(xy[0], 64)
+		y, _ := strconv.ParseFloat(xy[1], 64)
+		pts = append(pts, &tiled.Point{X: x, Y: y})
+	}
+
+	if isClosed && len(pts) > 2 {
+		pts = append(pts, &tiled.Point{X: pts[0].X, Y: pts[0].Y})
+	}
+
+	return pts
+}
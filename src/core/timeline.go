@@ -0,0 +1,656 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/raylib-go/raymath"
+	"github.com/zaklaus/rurik/src/system"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// TimelineTrackCamera drives the camera
+	TimelineTrackCamera = "camera"
+
+	// TimelineTrackMove moves an object
+	TimelineTrackMove = "move"
+
+	// TimelineTrackAnim plays animation tags of an object
+	TimelineTrackAnim = "anim"
+
+	// TimelineTrackDialogue starts dialogues
+	TimelineTrackDialogue = "dialogue"
+
+	// TimelineTrackEvent fires script events
+	TimelineTrackEvent = "event"
+
+	// TimelineTrackQuest adds quests and calls quest events
+	TimelineTrackQuest = "quest"
+)
+
+const (
+	timelinePlay = iota
+	timelineSkip
+	timelineScrub
+)
+
+var (
+	// DialogueHandler is called by the timeline to start a dialogue,
+	// the game is expected to set IsInDialogue until the dialogue ends
+	DialogueHandler func(name string)
+
+	// ActiveTimeline is the currently playing cutscene
+	ActiveTimeline *TimelinePlayer
+
+	timelines                 = make(map[string]*Timeline)
+	timelineNodeIsCollapsed   = true
+	timelineScrubValue        float64
+	timelineLastScrubValue    float64
+	timelineIsPausedForEditor bool
+)
+
+// Timeline is a cutscene, loaded from "timelines/<name>.yaml" of the map or the game
+type Timeline struct {
+	Name     string
+	Duration float32          `yaml:"duration"`
+	Locked   bool             `yaml:"locked"`
+	Event    string           `yaml:"event"`
+	Tracks   []*TimelineTrack `yaml:"tracks"`
+}
+
+// TimelineTrack is a sequence of keys of the same kind
+type TimelineTrack struct {
+	Type   string         `yaml:"type"`
+	Target string         `yaml:"target"`
+	Keys   []*TimelineKey `yaml:"keys"`
+}
+
+// TimelineKey is a single step of a track, keys with a duration are interpolated
+type TimelineKey struct {
+	Time     float32 `yaml:"time"`
+	Duration float32 `yaml:"duration"`
+	Easing   string  `yaml:"easing"`
+
+	// Action selects the camera behaviour: follow, move, rail, zoom, shake, frame, bounds, push or pop
+	Action string  `yaml:"action"`
+	Target string  `yaml:"target"`
+	From   string  `yaml:"from"`
+	To     string  `yaml:"to"`
+	Path   string  `yaml:"path"`
+	Zoom   float32 `yaml:"zoom"`
+	Trauma float32 `yaml:"trauma"`
+	Tag    string  `yaml:"tag"`
+	Name   string  `yaml:"name"`
+	Event  string  `yaml:"event"`
+	Args   string  `yaml:"args"`
+	Wait   bool    `yaml:"wait"`
+}
+
+// TimelinePlayer plays a timeline, the state of interpolated keys depends only on the time
+type TimelinePlayer struct {
+	Timeline *Timeline
+	Time     float32
+	Playing  bool
+	Paused   bool
+
+	waiting bool
+	states  [][]timelineKeyState
+}
+
+type timelineKeyState struct {
+	started  bool
+	finished bool
+	fired    bool
+	captured bool
+	from     rl.Vector2
+	fromZoom float32
+	spline   *Spline
+}
+
+// GetTimeline loads the timeline or returns the cached one
+func GetTimeline(name string) *Timeline {
+	fileName := fmt.Sprintf("map/%s/timelines/%s.yaml", system.MapName, name)
+	asset := system.FindAsset(fileName)
+
+	if asset == nil {
+		fileName = fmt.Sprintf("timelines/%s.yaml", name)
+		asset = system.FindAsset(fileName)
+	}
+
+	if asset == nil {
+		log.Printf("Timeline '%s' could not be found!\n", name)
+		return nil
+	}
+
+	if t, ok := timelines[fileName]; ok {
+		return t
+	}
+
+	t := &Timeline{Name: name}
+
+	if err := yaml.Unmarshal(asset.Data, t); err != nil {
+		log.Printf("Timeline '%s' could not be loaded: %s\n", name, err.Error())
+		return nil
+	}
+
+	for _, tr := range t.Tracks {
+		sort.SliceStable(tr.Keys, func(i, j int) bool {
+			return tr.Keys[i].Time < tr.Keys[j].Time
+		})
+
+		for _, k := range tr.Keys {
+			t.Duration = float32(math.Max(float64(t.Duration), float64(k.Time+k.Duration)))
+		}
+	}
+
+	timelines[fileName] = t
+	return t
+}
+
+// FlushTimelines drops the cached timelines, so they get reloaded on the next use
+func FlushTimelines() {
+	timelines = make(map[string]*Timeline)
+}
+
+// PlayTimeline starts the cutscene, the previous one gets skipped to its end
+func PlayTimeline(name string) *TimelinePlayer {
+	t := GetTimeline(name)
+
+	if t == nil {
+		return nil
+	}
+
+	if ActiveTimeline != nil {
+		ActiveTimeline.Skip()
+	}
+
+	p := &TimelinePlayer{
+		Timeline: t,
+		Playing:  true,
+		states:   make([][]timelineKeyState, len(t.Tracks)),
+	}
+
+	for i, tr := range t.Tracks {
+		p.states[i] = make([]timelineKeyState, len(tr.Keys))
+	}
+
+	ActiveTimeline = p
+	CanSave = BitsSet(CanSave, IsSequenceHappening)
+
+	log.Printf("Playing timeline '%s'...\n", name)
+	p.advance(0, timelinePlay)
+
+	return p
+}
+
+// IsTimelinePlaying checks whether a cutscene is in progress
+func IsTimelinePlaying() bool {
+	return ActiveTimeline != nil && ActiveTimeline.Playing
+}
+
+// SkipTimeline skips the active cutscene to its end state
+func SkipTimeline() {
+	if ActiveTimeline != nil {
+		ActiveTimeline.Skip()
+	}
+}
+
+// StopTimeline stops the active cutscene, leaving the world as it is
+func StopTimeline() {
+	if ActiveTimeline != nil {
+		ActiveTimeline.Stop()
+	}
+}
+
+// Skip applies the end state of all keys and finishes the timeline.
+// Dialogues and camera shakes are left out
+func (p *TimelinePlayer) Skip() {
+	if !p.Playing {
+		return
+	}
+
+	p.waiting = false
+	p.advance(p.Timeline.Duration, timelineSkip)
+	p.finish()
+}
+
+// Stop ends the timeline without reaching its end
+func (p *TimelinePlayer) Stop() {
+	if !p.Playing {
+		return
+	}
+
+	p.Playing = false
+	p.release()
+}
+
+// Seek moves the playhead, interpolated keys are evaluated at the new time,
+// events, quests, dialogues and shakes on the way are not fired
+func (p *TimelinePlayer) Seek(t float32) {
+	p.waiting = false
+	p.advance(t, timelineScrub)
+}
+
+// Update advances the timeline, dialogues marked with wait pause it until they end
+func (p *TimelinePlayer) Update(dt float32) {
+	if !p.Playing || p.Paused {
+		return
+	}
+
+	if p.waiting {
+		if BitsHas(CanSave, IsInDialogue) {
+			return
+		}
+
+		p.waiting = false
+	}
+
+	if !p.Timeline.Locked && system.IsKeyPressed("skip") {
+		p.Skip()
+		return
+	}
+
+	p.advance(p.Time+dt, timelinePlay)
+
+	if p.Time >= p.Timeline.Duration && !p.waiting {
+		p.finish()
+	}
+}
+
+func (p *TimelinePlayer) finish() {
+	p.Playing = false
+	p.release()
+
+	log.Printf("Timeline '%s' has finished!\n", p.Timeline.Name)
+
+	if p.Timeline.Event != "" {
+		FireEvent(p.Timeline.Event, p.Timeline.Name)
+	}
+}
+
+func (p *TimelinePlayer) release() {
+	if ActiveTimeline == p {
+		ActiveTimeline = nil
+	}
+
+	CanSave = BitsClear(CanSave, IsSequenceHappening)
+}
+
+// advance moves the playhead and evaluates all keys on the way
+func (p *TimelinePlayer) advance(t float32, mode int) {
+	p.Time = float32(math.Max(0, math.Min(float64(t), float64(p.Timeline.Duration))))
+
+	for i, tr := range p.Timeline.Tracks {
+		for j, k := range tr.Keys {
+			st := &p.states[i][j]
+
+			if !isTimelineKeyInterpolated(tr, k) {
+				if p.Time < k.Time {
+					st.fired = false
+				} else if !st.fired {
+					st.fired = true
+					p.fireKey(tr, k, st, mode)
+				}
+
+				continue
+			}
+
+			if p.Time < k.Time {
+				if st.started {
+					p.applyKey(tr, k, st, 0)
+				}
+
+				st.started = false
+				st.finished = false
+				continue
+			}
+
+			if p.Time < k.Time+k.Duration {
+				st.finished = false
+			}
+
+			if !st.started {
+				st.started = true
+				p.beginKey(tr, k, st)
+			}
+
+			if st.finished {
+				continue
+			}
+
+			progress := (p.Time - k.Time) / k.Duration
+			p.applyKey(tr, k, st, Ease(k.Easing, progress))
+
+			if progress >= 1 {
+				st.finished = true
+			}
+		}
+	}
+}
+
+func isTimelineKeyInterpolated(tr *TimelineTrack, k *TimelineKey) bool {
+	if k.Duration <= 0 {
+		return false
+	}
+
+	switch tr.Type {
+	case TimelineTrackMove:
+		return true
+	case TimelineTrackCamera:
+		return k.Action == "move" || k.Action == "rail" || k.Action == "zoom"
+	}
+
+	return false
+}
+
+// beginKey captures the state the key interpolates from, the state is kept for scrubbing
+func (p *TimelinePlayer) beginKey(tr *TimelineTrack, k *TimelineKey, st *timelineKeyState) {
+	o := p.getTrackObject(tr)
+
+	if o == nil || st.captured {
+		return
+	}
+
+	st.captured = true
+	st.from = o.Position
+	st.fromZoom = o.Zoom
+
+	if k.From != "" {
+		st.from = getTimelinePoint(k.From, st.from)
+	}
+
+	if k.Path != "" {
+		if path, _ := CurrentMap.World.FindObject(k.Path); path != nil {
+			if points := GetPolyLinePoints(path); len(points) > 1 {
+				st.spline = NewSpline(points)
+			}
+		}
+
+		if st.spline == nil {
+			log.Printf("Timeline '%s' uses an invalid path '%s'!\n", p.Timeline.Name, k.Path)
+		}
+	}
+}
+
+// applyKey evaluates the interpolated key at the eased progress
+func (p *TimelinePlayer) applyKey(tr *TimelineTrack, k *TimelineKey, st *timelineKeyState, t float32) {
+	o := p.getTrackObject(tr)
+
+	if o == nil || !st.captured {
+		return
+	}
+
+	if tr.Type == TimelineTrackCamera {
+		if k.Action == "zoom" {
+			o.SetCameraZoom(ScalarLerp(st.fromZoom, k.Zoom, t))
+			return
+		}
+
+		o.Mode = CameraModeStatic
+	}
+
+	pos := o.Position
+
+	if st.spline != nil {
+		pos = st.spline.PointAt(t)
+	} else if k.To != "" {
+		pos = Vector2Lerp(st.from, getTimelinePoint(k.To, st.from), t)
+	}
+
+	if tr.Type == TimelineTrackMove && (pos.X != o.Position.X || pos.Y != o.Position.Y) {
+		o.Facing = raymath.Vector2Subtract(pos, o.Position)
+		raymath.Vector2Normalize(&o.Facing)
+	}
+
+	o.SetPosition(pos.X, pos.Y)
+}
+
+// fireKey performs the instant key, the mode decides which side effects take place
+func (p *TimelinePlayer) fireKey(tr *TimelineTrack, k *TimelineKey, st *timelineKeyState, mode int) {
+	switch tr.Type {
+	case TimelineTrackAnim:
+		o := p.getTrackObject(tr)
+
+		if o != nil && o.Ase != nil {
+			PlayAnim(o, k.Tag)
+		}
+
+	case TimelineTrackEvent:
+		if mode != timelineScrub {
+			FireEvent(k.Event, CompileEventArgs(k.Args))
+		}
+
+	case TimelineTrackQuest:
+		if mode != timelineScrub {
+			p.fireQuestKey(k)
+		}
+
+	case TimelineTrackDialogue:
+		if mode != timelinePlay {
+			return
+		}
+
+		if DialogueHandler == nil {
+			log.Printf("Timeline '%s' starts a dialogue, but no dialogue handler is set!\n", p.Timeline.Name)
+			return
+		}
+
+		DialogueHandler(k.Name)
+		p.waiting = k.Wait
+
+	case TimelineTrackCamera:
+		p.fireCameraKey(tr, k, mode)
+
+	default:
+		log.Printf("Timeline '%s' has an unknown track type '%s'!\n", p.Timeline.Name, tr.Type)
+	}
+}
+
+func (p *TimelinePlayer) fireCameraKey(tr *TimelineTrack, k *TimelineKey, mode int) {
+	c := p.getTrackObject(tr)
+
+	if c == nil {
+		return
+	}
+
+	switch k.Action {
+	case "follow":
+		target := LocalPlayer
+
+		if k.Target != "" {
+			target, _ = CurrentMap.World.FindObject(k.Target)
+		}
+
+		c.SetCameraFollow(target)
+
+	case "move", "rail":
+		// NOTE: instant moves, interpolated ones are handled by applyKey
+		c.Mode = CameraModeStatic
+		st := &timelineKeyState{}
+		p.beginKey(tr, k, st)
+		p.applyKey(tr, k, st, 1)
+
+	case "zoom":
+		c.SetCameraZoom(k.Zoom)
+
+	case "shake":
+		if mode == timelinePlay {
+			c.AddCameraTrauma(k.Trauma)
+		}
+
+	case "frame":
+		c.SetCameraTargets(splitNameList(k.Target))
+
+	case "bounds":
+		if k.Target == "" {
+			c.ClearCameraBounds()
+		} else {
+			c.SetCameraBounds(k.Target)
+		}
+
+	case "push":
+		blend := k.Duration
+
+		if mode != timelinePlay {
+			blend = 0
+		}
+
+		PushCamera(c, blend)
+
+	case "pop":
+		blend := k.Duration
+
+		if mode != timelinePlay {
+			blend = 0
+		}
+
+		PopCamera(blend)
+
+	default:
+		log.Printf("Timeline '%s' has an unknown camera action '%s'!\n", p.Timeline.Name, k.Action)
+	}
+}
+
+// fireQuestKey adds the named quest, or calls the quest event with numeric arguments
+func (p *TimelinePlayer) fireQuestKey(k *TimelineKey) {
+	if k.Name != "" {
+		if ok, msg, _ := Quests.AddQuest(k.Name, nil); !ok {
+			log.Printf("Timeline '%s' could not add quest '%s': %s\n", p.Timeline.Name, k.Name, msg)
+		}
+	}
+
+	if k.Event == "" {
+		return
+	}
+
+	args := []float64{}
+
+	for _, v := range splitNameList(k.Args) {
+		f, _ := strconv.ParseFloat(v, 64)
+		args = append(args, f)
+	}
+
+	Quests.CallEvent(-1, k.Event, args)
+}
+
+// getTrackObject resolves the track's target, camera tracks default to the main camera
+func (p *TimelinePlayer) getTrackObject(tr *TimelineTrack) *Object {
+	if tr.Target == "" {
+		if tr.Type == TimelineTrackCamera {
+			return MainCamera
+		}
+
+		return nil
+	}
+
+	if CurrentMap == nil {
+		return nil
+	}
+
+	o, _ := CurrentMap.World.FindObject(tr.Target)
+
+	if o == nil {
+		log.Printf("Timeline '%s' targets unknown object '%s'!\n", p.Timeline.Name, tr.Target)
+	}
+
+	return o
+}
+
+// getTimelinePoint resolves an object's name or "x y" coordinates
+func getTimelinePoint(value string, fallback rl.Vector2) rl.Vector2 {
+	if strings.ContainsAny(value, "0123456789") && strings.Contains(strings.TrimSpace(value), " ") {
+		return StringToVec2(value)
+	}
+
+	if CurrentMap == nil {
+		return fallback
+	}
+
+	o, _ := CurrentMap.World.FindObject(value)
+
+	if o == nil {
+		log.Printf("Timeline point '%s' could not be found!\n", value)
+		return fallback
+	}
+
+	return o.Position
+}
+
+func updateTimeline(dt float32) {
+	if ActiveTimeline != nil {
+		ActiveTimeline.Update(dt)
+	}
+}
+
+func drawTimelineUI(mapNode *EditorElement) {
+	node := PushEditorElement(mapNode, "timeline", &timelineNodeIsCollapsed)
+
+	if timelineNodeIsCollapsed {
+		return
+	}
+
+	p := ActiveTimeline
+
+	if p == nil {
+		PushEditorElement(node, "no timeline is playing", nil)
+		return
+	}
+
+	if timelineScrubValue != timelineLastScrubValue {
+		p.Paused = true
+		p.Seek(float32(timelineScrubValue))
+	}
+
+	timelineScrubValue = float64(p.Time)
+	timelineLastScrubValue = timelineScrubValue
+
+	PushEditorElement(node, fmt.Sprintf("name: %s", p.Timeline.Name), nil)
+	PushEditorElement(node, fmt.Sprintf("time: %.02f/%.02f", p.Time, p.Timeline.Duration), nil)
+
+	scrub := PushEditorElement(node, "Time:", nil)
+	SetUpSlider(scrub, &timelineScrubValue, 0, float64(p.Timeline.Duration))
+
+	pauseText := "Pause"
+
+	if p.Paused {
+		pauseText = "Resume"
+	}
+
+	SetUpButton(
+		PushEditorElement(node, pauseText, nil),
+		func() {
+			p.Paused = !p.Paused
+		},
+		true,
+	)
+
+	SetUpButton(
+		PushEditorElement(node, "Skip", nil),
+		func() {
+			p.Skip()
+		},
+		true,
+	)
+}
@@ -1,146 +1,332 @@
-/*
-   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
-
-   Licensed under the Apache License, Version 2.0 (the "License");
-   you may not use this file except in compliance with the License.
-   You may obtain a copy of the License at
-
-       http://www.apache.org/licenses/LICENSE-2.0
-
-   Unless required by applicable law or agreed to in writing, software
-   distributed under the License is distributed on an "AS IS" BASIS,
-   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
-   See the License for the specific language governing permissions and
-   limitations under the License.
-*/
-
-package core
-
-import (
-	"strconv"
-
-	rl "github.com/zaklaus/raylib-go/raylib"
-	"github.com/zaklaus/rurik/src/system"
-)
-
-var (
-	// SkyColor is the tint color used for drawn sprites/tiles
-	SkyColor rl.Color
-
-	// WeatherTimeScale specifies time cycle scale
-	WeatherTimeScale float64
-
-	weatherIsCollapsed = true
-)
-
-type weatherStage struct {
-	Name     string
-	Color    rl.Vector3
-	Duration float64
-}
-
-// Weather represents the map time and weather
-type Weather struct {
-	UseTimeCycle    bool
-	SkyStageName    string
-	SkyTime         float64
-	SkyTargetTime   float64
-	SkyStageIndex   int
-	SkyStages       []weatherStage
-	SkyLastColor    rl.Vector3
-	SkyCurrentColor rl.Vector3
-	SkyTargetColor  rl.Vector3
-}
-
-// WeatherInit sets up the mood by initializing Sky color tint and other properties
-func (w *Weather) WeatherInit(cmap *Map) {
-	var err error
-	w.SkyCurrentColor, err = GetColorFromHex(cmap.tilemap.Properties.GetString("skyColor"))
-
-	if err != nil {
-		SkyColor = rl.White
-	} else {
-		SkyColor = Vec3ToColor(w.SkyCurrentColor)
-	}
-
-	w.SkyStages = []weatherStage{}
-
-	w.appendSkyStage(cmap, "skyRiseColor", "riseDuration")
-	w.appendSkyStage(cmap, "skyDayColor", "dayDuration")
-	w.appendSkyStage(cmap, "skyDawnColor", "dawnDuration")
-	w.appendSkyStage(cmap, "skyNightColor", "nightDuration")
-
-	if len(w.SkyStages) > 0 {
-		w.SkyLastColor = w.SkyCurrentColor
-		w.SkyStageName = w.SkyStages[0].Name
-		w.SkyTargetColor = w.SkyStages[0].Color
-		w.SkyTime = w.SkyStages[0].Duration
-		w.SkyTargetTime = w.SkyTime
-		SkyColor = Vec3ToColor(w.SkyCurrentColor)
-		w.SkyStageIndex = 0
-
-		if err != nil {
-			w.SkyCurrentColor = w.SkyTargetColor
-			w.nextSkyStage()
-		}
-	}
-
-	weatherIsCollapsed = true
-}
-
-// UpdateWeather updates the time cycle and weather effects
-func (w *Weather) UpdateWeather() {
-	if w.UseTimeCycle {
-		if w.SkyTime <= 0 {
-			w.nextSkyStage()
-		} else {
-			w.SkyTime -= float64(system.FrameTime) * WeatherTimeScale
-		}
-
-		if w.SkyTargetTime != 0 {
-			w.SkyCurrentColor = LerpColor(w.SkyLastColor, w.SkyTargetColor, 1-w.SkyTime/w.SkyTargetTime)
-		} else {
-			w.SkyCurrentColor = w.SkyTargetColor
-		}
-
-		SkyColor = Vec3ToColor(w.SkyCurrentColor)
-	}
-}
-
-// DrawWeather draws weather effects
-func (w *Weather) DrawWeather() {
-
-}
-
-func (w *Weather) nextSkyStage() {
-	w.SkyStageIndex++
-
-	if w.SkyStageIndex >= len(w.SkyStages) {
-		w.SkyStageIndex = 0
-	}
-
-	stage := w.SkyStages[w.SkyStageIndex]
-	w.SkyStageName = stage.Name
-	w.SkyTime = stage.Duration
-	w.SkyTargetTime = w.SkyTime
-	w.SkyTargetColor = stage.Color
-	w.SkyLastColor = w.SkyCurrentColor
-}
-
-func (w *Weather) appendSkyStage(cmap *Map, SkyName, stageName string) {
-	color, err := GetColorFromHex(cmap.tilemap.Properties.GetString(SkyName))
-
-	if err == nil {
-		w.UseTimeCycle = true
-	} else {
-		return
-	}
-
-	duration, _ := strconv.ParseFloat(cmap.tilemap.Properties.GetString(stageName), 10)
-
-	w.SkyStages = append(w.SkyStages, weatherStage{
-		Name:     SkyName,
-		Color:    color,
-		Duration: duration * 60,
-	})
-}
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/rurik/src/system"
+)
+
+var (
+	// SkyColor is the tint color used for drawn sprites/tiles
+	SkyColor rl.Color
+
+	// WeatherTimeScale specifies the day cycle and weather time scale
+	WeatherTimeScale float64
+
+	// WeatherDefaultTransition is the time in seconds it takes to change the weather
+	WeatherDefaultTransition float32 = 10
+
+	// LightningFlashDecay is the rate at which the lightning flash fades out per second
+	LightningFlashDecay float32 = 4
+
+	weatherIsCollapsed = true
+
+	weatherStates = map[string]WeatherState{
+		"clear": {},
+		"rain":  {Rain: 0.5, Wind: 20, Darkness: 0.2},
+		"storm": {Rain: 1, Wind: 120, Darkness: 0.45, Lightning: 6, Fog: 0.15},
+		"snow":  {Snow: 0.6, Wind: 10, Darkness: 0.05},
+		"fog":   {Fog: 0.6, Darkness: 0.1},
+		"heat":  {Heat: 1},
+	}
+)
+
+// WeatherState describes the intensity of all weather effects
+type WeatherState struct {
+	// Rain and Snow are the precipitation densities in range 0..1
+	Rain float32
+	Snow float32
+
+	// Wind is the horizontal wind speed in pixels per second
+	Wind float32
+
+	// Fog and Heat are the strengths of the post-process passes in range 0..1
+	Fog  float32
+	Heat float32
+
+	// Darkness darkens the sky tint in range 0..1
+	Darkness float32
+
+	// Lightning is the average number of lightning flashes per minute
+	Lightning float32
+}
+
+// Weather represents the map time and weather
+type Weather struct {
+	UseTimeCycle    bool
+	SkyCurrentColor rl.Vector3
+
+	StateName      string
+	TargetName     string
+	Current        WeatherState
+	From           WeatherState
+	Transition     float32
+	TransitionTime float32
+	Cycle          []string
+	CycleIndex     int
+	CycleDuration  float64
+	CycleTime      float64
+	LightningFlash float32
+}
+
+// RegisterWeatherState adds a new weather state, or replaces an existing one
+func RegisterWeatherState(name string, state WeatherState) {
+	weatherStates[name] = state
+}
+
+// GetWeatherStateNames returns all registered weather states
+func GetWeatherStateNames() []string {
+	names := []string{}
+
+	for k := range weatherStates {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// WeatherInit sets up the mood by initializing Sky color tint and other properties.
+// Maps with the "dayCycle" property are tinted by the global day cycle
+func (w *Weather) WeatherInit(cmap *Map) {
+	var err error
+	w.SkyCurrentColor, err = GetColorFromHex(cmap.tilemap.Properties.GetString("skyColor"))
+
+	if err != nil {
+		w.SkyCurrentColor = ColorToVec3(rl.White)
+	}
+
+	w.UseTimeCycle = cmap.tilemap.Properties.GetString("dayCycle") == "1"
+
+	if w.UseTimeCycle {
+		w.SkyCurrentColor = GetDayCycleColor()
+	}
+
+	SkyColor = Vec3ToColor(w.SkyCurrentColor)
+
+	w.initWeatherStates(cmap)
+	flushPrecipitation()
+
+	weatherIsCollapsed = true
+}
+
+// initWeatherStates reads the map's weather properties:
+// "weather" is the initial state, "weatherCycle" is a list of states the weather goes through,
+// each lasting "weatherDuration" minutes and changing over "weatherTransition" seconds.
+// Custom states are defined as "weather_<name>" properties, e.g. "rain=0.3; wind=40; darkness=0.2"
+func (w *Weather) initWeatherStates(cmap *Map) {
+	for _, p := range *cmap.tilemap.Properties {
+		if !strings.HasPrefix(p.Name, "weather_") {
+			continue
+		}
+
+		state, err := parseWeatherState(p.Value)
+
+		if err != nil {
+			log.Printf("Weather state '%s' is invalid: %s!\n", p.Name, err.Error())
+			continue
+		}
+
+		RegisterWeatherState(strings.TrimPrefix(p.Name, "weather_"), state)
+	}
+
+	w.Cycle = splitNameList(cmap.tilemap.Properties.GetString("weatherCycle"))
+	w.CycleIndex = 0
+	w.CycleDuration, _ = strconv.ParseFloat(cmap.tilemap.Properties.GetString("weatherDuration"), 64)
+	w.CycleDuration *= 60
+	w.CycleTime = w.CycleDuration
+	w.Transition = WeatherDefaultTransition
+
+	if v := cmap.tilemap.Properties.GetString("weatherTransition"); v != "" {
+		transition, _ := strconv.ParseFloat(v, 32)
+		w.Transition = float32(transition)
+	}
+
+	initial := cmap.tilemap.Properties.GetString("weather")
+
+	if initial == "" && len(w.Cycle) > 0 {
+		initial = w.Cycle[0]
+	}
+
+	if initial == "" {
+		initial = "clear"
+	}
+
+	w.SetWeather(initial, 0)
+}
+
+// SetWeather starts the transition to the weather state, a negative time uses the map's transition time
+func (w *Weather) SetWeather(name string, transition float32) {
+	state, ok := weatherStates[name]
+
+	if !ok {
+		log.Printf("Weather state '%s' is undefined!\n", name)
+		return
+	}
+
+	if transition < 0 {
+		transition = w.Transition
+	}
+
+	w.From = w.Current
+	w.TargetName = name
+	w.TransitionTime = transition
+
+	if transition <= 0 {
+		w.Current = state
+		w.StateName = name
+		w.TransitionTime = 0
+	}
+}
+
+// UpdateWeather updates the sky tint and weather effects
+func (w *Weather) UpdateWeather() {
+	dt := float64(system.FrameTime) * WeatherTimeScale
+
+	if w.UseTimeCycle {
+		w.SkyCurrentColor = GetDayCycleColor()
+	}
+
+	w.updateWeatherCycle(dt)
+	w.updateWeatherTransition(float32(dt))
+	w.updateLightning(system.FrameTime * float32(TimeScale))
+
+	light := 1 - w.Current.Darkness
+	sky := rl.NewVector3(w.SkyCurrentColor.X*light, w.SkyCurrentColor.Y*light, w.SkyCurrentColor.Z*light)
+	SkyColor = Vec3ToColor(LerpColor(sky, ColorToVec3(rl.White), float64(w.LightningFlash)))
+
+	updatePrecipitation(w, system.FrameTime*float32(TimeScale))
+}
+
+// DrawWeather draws weather effects
+func (w *Weather) DrawWeather() {
+	drawPrecipitation()
+}
+
+func (w *Weather) updateWeatherCycle(dt float64) {
+	if len(w.Cycle) == 0 || w.CycleDuration <= 0 {
+		return
+	}
+
+	w.CycleTime -= dt
+
+	if w.CycleTime > 0 {
+		return
+	}
+
+	w.CycleTime = w.CycleDuration
+	w.CycleIndex = (w.CycleIndex + 1) % len(w.Cycle)
+	w.SetWeather(w.Cycle[w.CycleIndex], -1)
+}
+
+func (w *Weather) updateWeatherTransition(dt float32) {
+	if w.StateName == w.TargetName {
+		return
+	}
+
+	target := weatherStates[w.TargetName]
+	w.TransitionTime -= dt
+
+	if w.TransitionTime <= 0 {
+		w.Current = target
+		w.StateName = w.TargetName
+		return
+	}
+
+	duration := w.Transition
+
+	if duration < w.TransitionTime {
+		duration = w.TransitionTime
+	}
+
+	w.Current = lerpWeatherState(w.From, target, 1-w.TransitionTime/duration)
+}
+
+func (w *Weather) updateLightning(dt float32) {
+	w.LightningFlash -= LightningFlashDecay * dt
+
+	if w.LightningFlash < 0 {
+		w.LightningFlash = 0
+	}
+
+	if w.Current.Lightning <= 0 || rand.Float32() > w.Current.Lightning/60*dt {
+		return
+	}
+
+	w.LightningFlash = 0.6 + rand.Float32()*0.4
+	FireEvent("onLightning", w.LightningFlash)
+}
+
+func lerpWeatherState(a, b WeatherState, t float32) WeatherState {
+	return WeatherState{
+		Rain:      ScalarLerp(a.Rain, b.Rain, t),
+		Snow:      ScalarLerp(a.Snow, b.Snow, t),
+		Wind:      ScalarLerp(a.Wind, b.Wind, t),
+		Fog:       ScalarLerp(a.Fog, b.Fog, t),
+		Heat:      ScalarLerp(a.Heat, b.Heat, t),
+		Darkness:  ScalarLerp(a.Darkness, b.Darkness, t),
+		Lightning: ScalarLerp(a.Lightning, b.Lightning, t),
+	}
+}
+
+// parseWeatherState reads a ";"-separated list of "field=value" pairs
+func parseWeatherState(value string) (WeatherState, error) {
+	state := WeatherState{}
+
+	for _, v := range splitNameList(value) {
+		kv := strings.SplitN(v, "=", 2)
+
+		if len(kv) != 2 {
+			return state, fmt.Errorf("'%s' is not a field=value pair", v)
+		}
+
+		num, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 32)
+
+		if err != nil {
+			return state, fmt.Errorf("field '%s' has an invalid value", kv[0])
+		}
+
+		switch strings.ToLower(strings.TrimSpace(kv[0])) {
+		case "rain":
+			state.Rain = float32(num)
+		case "snow":
+			state.Snow = float32(num)
+		case "wind":
+			state.Wind = float32(num)
+		case "fog":
+			state.Fog = float32(num)
+		case "heat":
+			state.Heat = float32(num)
+		case "darkness":
+			state.Darkness = float32(num)
+		case "lightning":
+			state.Lightning = float32(num)
+		default:
+			return state, fmt.Errorf("field '%s' is unknown", kv[0])
+		}
+	}
+
+	return state, nil
+}
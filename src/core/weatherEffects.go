@@ -0,0 +1,286 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package core
+
+import (
+	"math"
+	"math/rand"
+
+	rl "github.com/zaklaus/raylib-go/raylib"
+	"github.com/zaklaus/rurik/src/system"
+)
+
+var (
+	// WeatherMaxParticles is the number of precipitation particles at full density
+	WeatherMaxParticles = 800
+
+	// RainColor is the color of rain drops
+	RainColor = rl.NewColor(174, 194, 224, 255)
+
+	// FogColor is the color of the fog, it gets tinted by the sky
+	FogColor = rl.NewColor(200, 205, 215, 255)
+
+	precipitation []precipitationParticle
+
+	weatherTexture system.RenderTarget
+	fogProgram     system.Program
+	heatProgram    system.Program
+)
+
+type precipitationParticle struct {
+	Position rl.Vector2
+	Speed    float32
+	Life     float32
+	MaxLife  float32
+	Phase    float32
+	IsSnow   bool
+}
+
+// ApplyWeatherEffects renders the heat and fog passes over the game world
+func ApplyWeatherEffects() {
+	if CurrentMap == nil {
+		return
+	}
+
+	w := &CurrentMap.Weather
+
+	if w.Current.Fog <= 0 && w.Current.Heat <= 0 {
+		return
+	}
+
+	if weatherTexture.ID == 0 || WindowWasResized {
+		if weatherTexture.ID != 0 {
+			rl.UnloadRenderTexture(weatherTexture)
+		}
+
+		weatherTexture = system.CreateRenderTarget(system.ScreenWidth, system.ScreenHeight)
+	}
+
+	weatherProfiler.StartInvocation()
+
+	if w.Current.Heat > 0 {
+		if heatProgram.Shader.ID == 0 {
+			heatProgram = system.NewProgramFromCode("", heatProgramSrcCode)
+		}
+
+		heatProgram.SetShaderValue("strength", []float32{w.Current.Heat}, 1)
+		heatProgram.RenderToTexture(WorldTexture, weatherTexture)
+		system.CopyToRenderTarget(weatherTexture, WorldTexture, false)
+	}
+
+	if w.Current.Fog > 0 {
+		if fogProgram.Shader.ID == 0 {
+			fogProgram = system.NewProgramFromCode("", fogProgramSrcCode)
+		}
+
+		camPos, camZoom := GetCameraView()
+		color := ColorToVec3(MixColor(FogColor, SkyColor))
+
+		fogProgram.SetShaderValue("density", []float32{w.Current.Fog}, 1)
+		fogProgram.SetShaderValue("wind", []float32{w.Current.Wind}, 1)
+		fogProgram.SetShaderValue("fogColor", []float32{color.X, color.Y, color.Z}, 3)
+		fogProgram.SetShaderValue("view", []float32{camPos.X, camPos.Y, camZoom}, 3)
+		fogProgram.RenderToTexture(WorldTexture, weatherTexture)
+		system.CopyToRenderTarget(weatherTexture, WorldTexture, false)
+	}
+
+	weatherProfiler.StopInvocation()
+}
+
+// updatePrecipitation keeps the rain drops and snow flakes within the camera's view
+func updatePrecipitation(w *Weather, dt float32) {
+	density := w.Current.Rain + w.Current.Snow
+	count := int(density * float32(WeatherMaxParticles))
+
+	if count <= 0 {
+		precipitation = precipitation[:0]
+		return
+	}
+
+	if count < len(precipitation) {
+		precipitation = precipitation[:count]
+	}
+
+	view := getFrustum()
+	snowRatio := w.Current.Snow / density
+
+	for len(precipitation) < count {
+		p := precipitationParticle{}
+		p.respawn(view, snowRatio)
+		precipitation = append(precipitation, p)
+	}
+
+	for i := range precipitation {
+		p := &precipitation[i]
+		p.Life -= dt
+
+		if p.Life <= 0 || !rl.CheckCollisionPointRec(p.Position, view) {
+			p.respawn(view, snowRatio)
+			continue
+		}
+
+		p.Position.X += p.getWind(w.Current.Wind) * dt
+		p.Position.Y += p.Speed * dt
+	}
+}
+
+// drawPrecipitation draws the rain streaks and snow flakes, fading them in and out
+func drawPrecipitation() {
+	if len(precipitation) == 0 {
+		return
+	}
+
+	wind := CurrentMap.Weather.Current.Wind
+
+	for _, p := range precipitation {
+		alpha := float32(math.Min(1, math.Min(float64(p.Life*4), float64((p.MaxLife-p.Life)*4))))
+
+		if p.IsSnow {
+			rl.DrawCircleV(p.Position, 1.2, rl.Fade(rl.White, alpha*0.8))
+			continue
+		}
+
+		tail := rl.NewVector2(
+			p.Position.X-p.getWind(wind)*0.02,
+			p.Position.Y-p.Speed*0.02,
+		)
+
+		rl.DrawLineV(tail, p.Position, rl.Fade(RainColor, alpha*0.6))
+	}
+}
+
+func flushPrecipitation() {
+	precipitation = []precipitationParticle{}
+}
+
+func (p *precipitationParticle) respawn(view rl.Rectangle, snowRatio float32) {
+	p.IsSnow = rand.Float32() < snowRatio
+	p.Position = rl.NewVector2(
+		view.X+rand.Float32()*view.Width,
+		view.Y+rand.Float32()*view.Height,
+	)
+	p.Phase = rand.Float32() * math.Pi * 2
+
+	if p.IsSnow {
+		p.Speed = 25 + rand.Float32()*20
+		p.MaxLife = 2 + rand.Float32()*3
+	} else {
+		p.Speed = 350 + rand.Float32()*100
+		p.MaxLife = 0.3 + rand.Float32()*0.5
+	}
+
+	p.Life = p.MaxLife
+}
+
+// getWind returns the horizontal speed, snow flakes are slowed down and sway around
+func (p *precipitationParticle) getWind(wind float32) float32 {
+	if !p.IsSnow {
+		return wind
+	}
+
+	return wind*0.6 + float32(math.Sin(float64(p.Phase+p.Life*2)))*10
+}
+
+/* Built-in shaders */
+
+const heatProgramSrcCode = `
+#version 330
+
+// Input vertex attributes (from vertex shader)
+in vec2 fragTexCoord;
+in vec4 fragColor;
+
+// Input uniform values
+uniform sampler2D texture0;
+uniform vec4 colDiffuse;
+uniform float time;
+uniform vec2 size = vec2(640, 480);
+uniform float strength;
+
+// Output fragment color
+out vec4 finalColor;
+
+void main()
+{
+    vec2 uv = fragTexCoord;
+    float wave = sin(uv.y * 80.0 + time * 4.0) + sin(uv.y * 37.0 - time * 2.5);
+    uv.x += wave * strength * 0.75 / size.x;
+    uv.y += cos(uv.x * 60.0 + time * 3.0) * strength * 0.5 / size.y;
+
+    finalColor = vec4(texture(texture0, uv).rgb, 1.0);
+}
+`
+
+const fogProgramSrcCode = `
+#version 330
+
+// Input vertex attributes (from vertex shader)
+in vec2 fragTexCoord;
+in vec4 fragColor;
+
+// Input uniform values
+uniform sampler2D texture0;
+uniform vec4 colDiffuse;
+uniform float time;
+uniform vec2 size = vec2(640, 480);
+uniform float density;
+uniform float wind;
+uniform vec3 fogColor;
+uniform vec3 view; // camera position and zoom
+
+// Output fragment color
+out vec4 finalColor;
+
+float hash(vec2 p) {
+    return fract(sin(dot(p, vec2(127.1, 311.7))) * 43758.5453);
+}
+
+float noise(vec2 p) {
+    vec2 i = floor(p);
+    vec2 f = fract(p);
+    f = f * f * (3.0 - 2.0 * f);
+
+    return mix(mix(hash(i), hash(i + vec2(1.0, 0.0)), f.x),
+               mix(hash(i + vec2(0.0, 1.0)), hash(i + vec2(1.0, 1.0)), f.x), f.y);
+}
+
+float fbm(vec2 p) {
+    float v = 0.0;
+    float a = 0.5;
+
+    for (int i = 0; i < 4; ++i) {
+        v += a * noise(p);
+        p *= 2.0;
+        a *= 0.5;
+    }
+
+    return v;
+}
+
+void main()
+{
+    vec3 col = texture(texture0, fragTexCoord).rgb;
+
+    // fog is locked to the world, so it moves along with the camera
+    vec2 screen = vec2(fragTexCoord.x, 1.0 - fragTexCoord.y) * size;
+    vec2 world = (screen - size * 0.5) / view.z + view.xy;
+    vec2 p = world / 160.0 + vec2(time * (wind + 8.0) / 160.0, time * 0.02);
+
+    float amount = clamp(density * (0.45 + fbm(p) * 0.9), 0.0, 1.0);
+    finalColor = vec4(mix(col, fogColor, amount), 1.0);
+}
+`
@@ -6,6 +6,7 @@ import (
 	"math"
 	"strings"
 
+	tiled "github.com/zaklaus/go-tiled"
 	rl "github.com/zaklaus/raylib-go/raylib"
 	"github.com/zaklaus/rurik/src/core"
 	"github.com/zaklaus/rurik/src/system"
@@ -26,6 +27,12 @@ func (g *demoGameMode) Init() {
 
 	// player class
 	core.RegisterClass("player", NewPlayer)
+	core.RegisterClassDependencies("player", func(m *core.MapManifest, o *tiled.Object) {
+		m.AddTexture("gfx/player.png")
+		m.AddAnim("gfx/player.json")
+	})
+
+	core.RegisterFileDependencies(scanDialogueDependencies)
 
 	if err != nil {
 		fmt.Printf("Custom type registration has failed: %s", err.Error())
@@ -80,6 +87,9 @@ func (g *demoGameMode) Update() {
 			g.playState = stateMenu
 		}
 
+	case stateLoading:
+		g.updateLoading()
+
 	case statePlay:
 		core.UpdateMaps()
 		core.Quests.ProcessQuests()
@@ -142,6 +152,9 @@ func (g *demoGameMode) DrawUI() {
 		core.DrawTextCentered("Rurik Framework", system.ScreenWidth/2, system.ScreenHeight/2-20+g.textWave, 24, rl.RayWhite)
 		g.drawLevelSelection()
 
+	case stateLoading:
+		g.drawLoading()
+
 	case statePlay:
 		core.DrawMapUI()
 		drawDialogue()
@@ -216,11 +229,16 @@ func (g *demoGameMode) PostDraw() {
 		fallthrough
 
 	case statePlay:
+		// Applies the fog and heat passes
+		core.ApplyWeatherEffects()
+
 		// Generates and applies the lightmaps
 		core.UpdateLightingSolution()
 
+		// Runs the map's post-processing passes
+		core.ApplyPostFX()
+
 		if core.CurrentMap.Name == "village" || core.CurrentMap.Name == "sewer" {
-			bloom.Apply()
 			minimap.Apply()
 			shadertoy.Apply()
 		}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"math"
 
 	rl "github.com/zaklaus/raylib-go/raylib"
@@ -21,6 +22,9 @@ var levelSelection struct {
 	waveTime             int32
 	banner               string
 	mouseDoublePressTime int32
+	loadingMap           string
+	preload              *system.AssetPreload
+	loadProgress         float32
 }
 
 func initLevels() {
@@ -144,6 +148,41 @@ func (g *demoGameMode) playLevelSelection() {
 		return
 	}
 
-	g.loadLevel(mapName)
+	preload, err := core.PreloadMap(mapName, func(done, total int) {
+		if total > 0 {
+			levelSelection.loadProgress = float32(done) / float32(total)
+		}
+	})
+
+	if err != nil {
+		log.Printf("Map '%s' could not be preloaded: %s\n", mapName, err.Error())
+		g.loadLevel(mapName)
+		g.playState = statePlay
+		return
+	}
+
+	levelSelection.loadingMap = mapName
+	levelSelection.preload = preload
+	levelSelection.loadProgress = 0
+	g.playState = stateLoading
+}
+
+func (g *demoGameMode) updateLoading() {
+	if !levelSelection.preload.IsDone() {
+		return
+	}
+
+	g.loadLevel(levelSelection.loadingMap)
+	levelSelection.preload = nil
 	g.playState = statePlay
 }
+
+func (g *demoGameMode) drawLoading() {
+	width := system.ScreenWidth / 2
+	x := system.ScreenWidth/2 - width/2
+	y := system.ScreenHeight / 2
+
+	core.DrawTextCentered(fmt.Sprintf("Loading %s...", levelSelection.loadingMap), system.ScreenWidth/2, y-20, 14, rl.RayWhite)
+	rl.DrawRectangle(x, y, width, 10, rl.Fade(rl.Black, 0.5))
+	rl.DrawRectangle(x, y, int32(float32(width)*levelSelection.loadProgress), 10, rl.DarkPurple)
+}
@@ -0,0 +1,872 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package system
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	fileTypeGeneric = iota
+	fileTypeSprite
+	fileTypeAseprite
+	fileTypeMap
+	fileTypeMusic
+)
+
+const (
+	archiveMagic         = "RDTA"
+	archiveVersionLegacy = 1
+	archiveVersion       = 2
+	archiveHeaderSize    = len(archiveMagic) + 2
+	archiveFlagEncrypt   = 1 << 0
+	archiveFlagSigned    = 1 << 1
+	archiveSignatureSize = ed25519.PublicKeySize + ed25519.SignatureSize
+
+	// archiveMaxTOCSize limits the table of contents read before the signature is checked
+	archiveMaxTOCSize = 64 << 20
+)
+
+var errLegacyArchive = errors.New("legacy archive")
+
+type annotationTag struct {
+	Name  string  `yaml:"name"`
+	Value float32 `yaml:"value"`
+}
+
+type annotationChunk struct {
+	IsHeader    bool              `yaml:"default"`
+	FileName    string            `yaml:"file"`
+	Name        string            `yaml:"name"`
+	Author      string            `yaml:"author"`
+	Description string            `yaml:"desc"`
+	Type        string            `yaml:"type"`
+	Tags        []annotationTag   `yaml:"tags"`
+	ExtraData   string            `yaml:"extra"`
+	VariantOf   string            `yaml:"variantof"`
+	Axes        map[string]string `yaml:"axes"`
+}
+
+type annotationAxis struct {
+	Name    string   `yaml:"name"`
+	Values  []string `yaml:"values"`
+	Default string   `yaml:"default"`
+	Weight  float64  `yaml:"weight"`
+}
+
+type annotationData struct {
+	Name        string `yaml:"name"`
+	Author      string `yaml:"author"`
+	Version     string `yaml:"version"`
+	Description string `yaml:"desc"`
+
+	Axes   []annotationAxis  `yaml:"axes"`
+	Chunks []annotationChunk `yaml:"chunks"`
+}
+
+// AssetChunk describes an asset file
+type AssetChunk struct {
+	FileName    string
+	Name        string
+	Author      string
+	Description string
+	Type        uint16
+	Data        []byte
+	Tags        []uint64
+	TagValues   []float64
+	ExtraData   []byte
+
+	// TagKeys holds the names of the chunk's tags, they are matched against RegisteredTags at runtime,
+	// so archives built outside of the game keep their tags
+	TagKeys  []string
+	Checksum []byte
+
+	// Variant is the name of the file this chunk is a variant of
+	Variant string
+	// Axes holds the values of variant axes this chunk matches
+	Axes map[string]string
+}
+
+// AssetArchive describes the game data
+type AssetArchive struct {
+	Name        string
+	Author      string
+	Version     string
+	Description string
+
+	Axes   []AssetAxis
+	Chunks []AssetChunk
+
+	encrypted bool
+	signer    ed25519.PublicKey
+	source    io.ReaderAt
+	closer    io.Closer
+	offsets   []int64
+	sizes     []int64
+	blobStart int64
+}
+
+// archiveTOC is the table of contents stored in front of the chunk data
+type archiveTOC struct {
+	Name        string
+	Author      string
+	Version     string
+	Description string
+
+	Axes    []AssetAxis
+	Chunks  []AssetChunk
+	Offsets []int64
+	Sizes   []int64
+}
+
+// parseAnnotationFile reads the archive description stored in a .rtag file
+func parseAnnotationFile(tagFileName string) (annotationData, error) {
+	var tags annotationData
+
+	data, err := ioutil.ReadFile(tagFileName)
+
+	if err != nil {
+		return tags, fmt.Errorf("tag file '%s' could not be read: %s", tagFileName, err.Error())
+	}
+
+	err = yaml.Unmarshal(data, &tags)
+
+	if err != nil {
+		return tags, fmt.Errorf("tag file '%s' could not be parsed: %s", tagFileName, err.Error())
+	}
+
+	return tags, nil
+}
+
+// BuildArchive compiles the archive described by a .rtag file, asset paths are relative to assetsDir
+func BuildArchive(tagFileName, assetsDir string) (AssetArchive, error) {
+	an, err := parseAnnotationFile(tagFileName)
+
+	if err != nil {
+		return AssetArchive{}, err
+	}
+
+	var a AssetArchive
+	a.Name = an.Name
+	a.Author = an.Author
+	a.Description = an.Description
+	a.Version = an.Version
+	a.Chunks = []AssetChunk{}
+
+	axes := make(map[string]annotationAxis)
+
+	for _, v := range an.Axes {
+		if v.Name == "" {
+			return a, fmt.Errorf("%s: axis has no name specified", tagFileName)
+		}
+
+		axes[v.Name] = v
+		a.Axes = append(a.Axes, AssetAxis{
+			Name:    v.Name,
+			Values:  v.Values,
+			Default: v.Default,
+			Weight:  v.Weight,
+		})
+	}
+
+	lastName := a.Name
+	lastAuthor := a.Author
+	lastDescription := a.Description
+	lastType := ""
+	lastAxes := map[string]string{}
+
+	for idx, v := range an.Chunks {
+		if v.IsHeader {
+			setPropertyIfSet(&lastName, v.Name, lastName)
+			setPropertyIfSet(&lastAuthor, v.Author, lastAuthor)
+			setPropertyIfSet(&lastDescription, v.Description, lastDescription)
+			setPropertyIfSet(&lastType, v.Type, lastType)
+
+			if v.Axes != nil {
+				lastAxes = v.Axes
+			}
+			continue
+		}
+
+		if v.FileName == "" {
+			return a, fmt.Errorf("%s: chunk #%d has no file specified", tagFileName, idx)
+		}
+
+		var ac AssetChunk
+
+		setPropertyIfSet(&ac.Name, v.Name, lastName)
+		setPropertyIfSet(&ac.Author, v.Author, lastAuthor)
+		setPropertyIfSet(&ac.Description, v.Description, lastDescription)
+		ac.FileName = v.FileName
+
+		var chunkType string
+		setPropertyIfSet(&chunkType, v.Type, lastType)
+		ac.Type = mapFileTypeStringToID(chunkType)
+
+		ac.Data, err = ioutil.ReadFile(filepath.Join(assetsDir, filepath.FromSlash(v.FileName)))
+
+		if err != nil {
+			return a, fmt.Errorf("%s: chunk #%d references missing file '%s'", tagFileName, idx, v.FileName)
+		}
+
+		ac.Checksum = checksumChunkData(ac.Data)
+		ac.Tags = []uint64{}
+		ac.TagValues = []float64{}
+		ac.TagKeys = []string{}
+
+		for _, vt := range v.Tags {
+			ac.Tags = append(ac.Tags, findRegisteredTag(vt.Name))
+			ac.TagValues = append(ac.TagValues, float64(vt.Value))
+			ac.TagKeys = append(ac.TagKeys, vt.Name)
+		}
+
+		ac.ExtraData = []byte(v.ExtraData)
+		ac.Variant = v.VariantOf
+		ac.Axes = make(map[string]string)
+
+		for k, val := range lastAxes {
+			ac.Axes[k] = val
+		}
+
+		for k, val := range v.Axes {
+			ac.Axes[k] = val
+		}
+
+		for k, val := range ac.Axes {
+			axis, ok := axes[k]
+
+			if !ok {
+				return a, fmt.Errorf("%s: chunk #%d uses undeclared axis '%s'", tagFileName, idx, k)
+			}
+
+			if len(axis.Values) > 0 && !containsString(axis.Values, val) {
+				return a, fmt.Errorf("%s: chunk #%d uses unknown value '%s' of axis '%s'", tagFileName, idx, val, k)
+			}
+		}
+
+		if len(ac.Axes) > 0 && ac.Variant == "" {
+			return a, fmt.Errorf("%s: chunk #%d declares axes but is not a variant of any file", tagFileName, idx)
+		}
+
+		a.Chunks = append(a.Chunks, ac)
+	}
+
+	return a, nil
+}
+
+// EncodeArchive compresses, encrypts and signs the archive.
+// Chunks are packed separately behind a table of contents, so they can be read on demand.
+func EncodeArchive(a AssetArchive) ([]byte, error) {
+	var flags byte
+
+	if ArchiveEncryptionKey != "" {
+		flags |= archiveFlagEncrypt
+	}
+
+	if ArchiveSigningKey != nil {
+		flags |= archiveFlagSigned
+	}
+
+	toc := archiveTOC{
+		Name:        a.Name,
+		Author:      a.Author,
+		Version:     a.Version,
+		Description: a.Description,
+		Axes:        a.Axes,
+	}
+
+	var blobs bytes.Buffer
+
+	for _, v := range a.Chunks {
+		stored, err := packChunkData(v.Data, flags)
+
+		if err != nil {
+			return nil, fmt.Errorf("archive '%s': chunk '%s' could not be packed: %s", a.Name, v.FileName, err.Error())
+		}
+
+		toc.Offsets = append(toc.Offsets, int64(blobs.Len()))
+		toc.Sizes = append(toc.Sizes, int64(len(stored)))
+		blobs.Write(stored)
+
+		v.Data = nil
+		toc.Chunks = append(toc.Chunks, v)
+	}
+
+	var tb bytes.Buffer
+	err := gob.NewEncoder(&tb).Encode(toc)
+
+	if err != nil {
+		return nil, fmt.Errorf("archive '%s' could not be encoded: %s", a.Name, err.Error())
+	}
+
+	tocData, err := packChunkData(tb.Bytes(), flags)
+
+	if err != nil {
+		return nil, fmt.Errorf("archive '%s' could not be packed: %s", a.Name, err.Error())
+	}
+
+	header := append([]byte(archiveMagic), archiveVersion, flags)
+	tocLen := make([]byte, 4)
+	binary.LittleEndian.PutUint32(tocLen, uint32(len(tocData)))
+
+	out := bytes.NewBuffer(header)
+
+	if flags&archiveFlagSigned != 0 {
+		signer, signature := signArchiveData(concatBytes(header, tocLen, tocData))
+		out.Write(signer)
+		out.Write(signature)
+	}
+
+	out.Write(tocLen)
+	out.Write(tocData)
+	out.Write(blobs.Bytes())
+
+	return out.Bytes(), nil
+}
+
+// DecodeArchive verifies, decrypts and decompresses the whole archive
+func DecodeArchive(data []byte) (AssetArchive, error) {
+	var db AssetArchive
+
+	err := readArchiveTOC(bytes.NewReader(data), &db)
+
+	if err == errLegacyArchive {
+		return decodeLegacyArchive(data)
+	}
+
+	if err != nil {
+		return db, err
+	}
+
+	for i := range db.Chunks {
+		chunkData, err := db.ReadChunk(i)
+
+		if err != nil {
+			return db, err
+		}
+
+		db.Chunks[i].Data = chunkData
+	}
+
+	db.source = nil
+	return db, nil
+}
+
+// LoadArchiveFile reads and decodes the whole archive stored on disk
+func LoadArchiveFile(fileName string) (AssetArchive, error) {
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		return AssetArchive{}, fmt.Errorf("could not load game data from %s: %s", fileName, err.Error())
+	}
+
+	db, err := DecodeArchive(data)
+
+	if err != nil {
+		return db, fmt.Errorf("%s: %s", fileName, err.Error())
+	}
+
+	return db, nil
+}
+
+// OpenArchiveFile reads the archive's table of contents, chunk data is read on demand
+func OpenArchiveFile(fileName string) (*AssetArchive, error) {
+	f, err := os.Open(fileName)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not load game data from %s: %s", fileName, err.Error())
+	}
+
+	a := &AssetArchive{}
+	err = readArchiveTOC(f, a)
+
+	if err == errLegacyArchive {
+		f.Close()
+		db, err := LoadArchiveFile(fileName)
+		return &db, err
+	}
+
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %s", fileName, err.Error())
+	}
+
+	a.closer = f
+	return a, nil
+}
+
+// Close releases the file backing a lazily loaded archive
+func (a *AssetArchive) Close() error {
+	if a.closer == nil {
+		return nil
+	}
+
+	err := a.closer.Close()
+	a.closer = nil
+	a.source = nil
+	return err
+}
+
+// ReadChunk returns the chunk's data, reading it from the archive if it is not resident
+func (a *AssetArchive) ReadChunk(i int) ([]byte, error) {
+	ch := &a.Chunks[i]
+
+	if ch.Data != nil || a.source == nil {
+		return ch.Data, nil
+	}
+
+	stored := make([]byte, a.sizes[i])
+	_, err := a.source.ReadAt(stored, a.blobStart+a.offsets[i])
+
+	if err != nil {
+		return nil, fmt.Errorf("archive '%s': chunk '%s' could not be read: %s", a.Name, ch.FileName, err.Error())
+	}
+
+	data, err := unpackChunkData(stored, a.encrypted)
+
+	if err != nil {
+		return nil, fmt.Errorf("archive '%s': chunk '%s' %s", a.Name, ch.FileName, err.Error())
+	}
+
+	if ch.Checksum != nil && !bytes.Equal(ch.Checksum, checksumChunkData(data)) {
+		return nil, fmt.Errorf("archive '%s': chunk '%s' is corrupted", a.Name, ch.FileName)
+	}
+
+	return data, nil
+}
+
+// readArchiveTOC verifies the archive header and reads the table of contents
+func readArchiveTOC(r io.ReaderAt, a *AssetArchive) error {
+	header := make([]byte, archiveHeaderSize)
+
+	if _, err := r.ReadAt(header, 0); err != nil || string(header[:len(archiveMagic)]) != archiveMagic {
+		return errLegacyArchive
+	}
+
+	version := header[len(archiveMagic)]
+	flags := header[len(archiveMagic)+1]
+
+	if version == archiveVersionLegacy {
+		return errLegacyArchive
+	}
+
+	if version != archiveVersion {
+		return fmt.Errorf("unsupported archive version %d", version)
+	}
+
+	offset := int64(archiveHeaderSize)
+
+	var signer ed25519.PublicKey
+	var signature []byte
+
+	if flags&archiveFlagSigned != 0 {
+		sig := make([]byte, archiveSignatureSize)
+
+		if _, err := r.ReadAt(sig, offset); err != nil {
+			return fmt.Errorf("archive signature is truncated")
+		}
+
+		signer = ed25519.PublicKey(sig[:ed25519.PublicKeySize])
+		signature = sig[ed25519.PublicKeySize:]
+		offset += archiveSignatureSize
+	}
+
+	tocLen := make([]byte, 4)
+
+	if _, err := r.ReadAt(tocLen, offset); err != nil {
+		return fmt.Errorf("archive is truncated")
+	}
+
+	offset += int64(len(tocLen))
+	size := int64(binary.LittleEndian.Uint32(tocLen))
+
+	if size > archiveMaxTOCSize {
+		return fmt.Errorf("archive table of contents is too large")
+	}
+
+	if total, ok := readerSize(r); ok && offset+size > total {
+		return fmt.Errorf("archive is truncated")
+	}
+
+	tocData := make([]byte, size)
+
+	if _, err := r.ReadAt(tocData, offset); err != nil {
+		return fmt.Errorf("archive is truncated")
+	}
+
+	offset += int64(len(tocData))
+
+	err := verifyArchiveSignature(a, signer, concatBytes(header, tocLen, tocData), signature)
+
+	if err != nil {
+		return err
+	}
+
+	a.encrypted = flags&archiveFlagEncrypt != 0
+	raw, err := unpackChunkData(tocData, a.encrypted)
+
+	if err != nil {
+		return err
+	}
+
+	var toc archiveTOC
+	err = gob.NewDecoder(bytes.NewReader(raw)).Decode(&toc)
+
+	if err != nil {
+		return fmt.Errorf("archive data could not be decoded: %s", err.Error())
+	}
+
+	if len(toc.Offsets) != len(toc.Chunks) || len(toc.Sizes) != len(toc.Chunks) {
+		return fmt.Errorf("archive table of contents is malformed")
+	}
+
+	// NOTE: chunks are read using these later on, they must not point outside of the file
+	total, hasSize := readerSize(r)
+
+	for i, v := range toc.Chunks {
+		start, size := toc.Offsets[i], toc.Sizes[i]
+
+		if start < 0 || size < 0 || (hasSize && (start > total-offset || size > total-offset-start)) {
+			return fmt.Errorf("archive chunk '%s' lies outside of the archive", v.FileName)
+		}
+	}
+
+	a.Name = toc.Name
+	a.Author = toc.Author
+	a.Version = toc.Version
+	a.Description = toc.Description
+	a.Axes = toc.Axes
+	a.Chunks = toc.Chunks
+	a.offsets = toc.Offsets
+	a.sizes = toc.Sizes
+	a.blobStart = offset
+	a.source = r
+
+	return nil
+}
+
+// readerSize returns the size of the data behind the reader, if it is known
+func readerSize(r io.ReaderAt) (int64, bool) {
+	switch v := r.(type) {
+	case interface{ Size() int64 }:
+		return v.Size(), true
+	case *os.File:
+		info, err := v.Stat()
+
+		if err != nil {
+			return 0, false
+		}
+
+		return info.Size(), true
+	}
+
+	return 0, false
+}
+
+// decodeLegacyArchive reads archives stored as a single gob blob
+func decodeLegacyArchive(data []byte) (AssetArchive, error) {
+	var db AssetArchive
+
+	if len(data) >= archiveHeaderSize && string(data[:len(archiveMagic)]) == archiveMagic {
+		header := data[:archiveHeaderSize]
+		flags := header[len(archiveMagic)+1]
+		data = data[archiveHeaderSize:]
+
+		var signer ed25519.PublicKey
+		var signature []byte
+
+		if flags&archiveFlagSigned != 0 {
+			if len(data) < archiveSignatureSize {
+				return db, fmt.Errorf("archive signature is truncated")
+			}
+
+			signer = ed25519.PublicKey(data[:ed25519.PublicKeySize])
+			signature = data[ed25519.PublicKeySize:archiveSignatureSize]
+			data = data[archiveSignatureSize:]
+		}
+
+		err := verifyArchiveSignature(&db, signer, concatBytes(header, data), signature)
+
+		if err != nil {
+			return db, err
+		}
+
+		db.encrypted = flags&archiveFlagEncrypt != 0
+	} else {
+		// NOTE: archives built before the header was introduced are always encrypted
+		if err := verifyArchiveSignature(&db, nil, nil, nil); err != nil {
+			return db, err
+		}
+
+		db.encrypted = true
+	}
+
+	dat, err := unpackChunkData(data, db.encrypted)
+
+	if err != nil {
+		return db, err
+	}
+
+	signer, encrypted := db.signer, db.encrypted
+	err = gob.NewDecoder(bytes.NewReader(dat)).Decode(&db)
+
+	if err != nil {
+		return db, fmt.Errorf("archive data could not be decoded: %s", err.Error())
+	}
+
+	db.signer, db.encrypted = signer, encrypted
+	return db, nil
+}
+
+// packChunkData compresses and optionally encrypts the data
+func packChunkData(data []byte, flags byte) ([]byte, error) {
+	var fb bytes.Buffer
+	w := zlib.NewWriter(&fb)
+	w.Write(data)
+	w.Close()
+
+	if flags&archiveFlagEncrypt == 0 {
+		return fb.Bytes(), nil
+	}
+
+	return encrypt(fb.Bytes(), ArchiveEncryptionKey)
+}
+
+// unpackChunkData decrypts and decompresses the data
+func unpackChunkData(data []byte, encrypted bool) ([]byte, error) {
+	if encrypted {
+		var err error
+		data, err = decrypt(data, ArchiveEncryptionKey)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r, err := zlib.NewReader(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, fmt.Errorf("data is corrupted: %s", err.Error())
+	}
+
+	var out bytes.Buffer
+	_, err = out.ReadFrom(r)
+
+	if err != nil {
+		return nil, fmt.Errorf("data could not be inflated: %s", err.Error())
+	}
+
+	return out.Bytes(), nil
+}
+
+func concatBytes(parts ...[]byte) []byte {
+	out := []byte{}
+
+	for _, v := range parts {
+		out = append(out, v...)
+	}
+
+	return out
+}
+
+// WriteArchiveFile encodes the archive and stores it on disk
+func WriteArchiveFile(fileName string, a AssetArchive) error {
+	data, err := EncodeArchive(a)
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(fileName, data, 0644)
+}
+
+// FindChunk looks for a chunk by filename inside of the archive
+func (a *AssetArchive) FindChunk(fileName string) *AssetChunk {
+	for i := range a.Chunks {
+		if a.Chunks[i].FileName == fileName {
+			return &a.Chunks[i]
+		}
+	}
+
+	return nil
+}
+
+// Verify checks every chunk's data against its stored checksum
+func (a *AssetArchive) Verify() []error {
+	errs := []error{}
+	seen := make(map[string]bool)
+
+	for i, v := range a.Chunks {
+		if seen[v.FileName] {
+			errs = append(errs, fmt.Errorf("chunk #%d '%s' is duplicated", i, v.FileName))
+		}
+
+		seen[v.FileName] = true
+
+		if v.Checksum == nil {
+			errs = append(errs, fmt.Errorf("chunk #%d '%s' has no checksum", i, v.FileName))
+			continue
+		}
+
+		data, err := a.ReadChunk(i)
+
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if !bytes.Equal(v.Checksum, checksumChunkData(data)) {
+			errs = append(errs, fmt.Errorf("chunk #%d '%s' is corrupted", i, v.FileName))
+		}
+	}
+
+	return errs
+}
+
+// TypeName returns a human-readable name of the chunk's file type
+func (c *AssetChunk) TypeName() string {
+	return mapFileTypeIDToString(c.Type)
+}
+
+// TagNames returns all tag names used by the chunk
+func (c *AssetChunk) TagNames() []string {
+	if len(c.TagKeys) == len(c.Tags) {
+		return c.TagKeys
+	}
+
+	names := []string{}
+
+	for _, t := range c.Tags {
+		if int(t) < len(RegisteredTags) {
+			names = append(names, RegisteredTags[t])
+		} else {
+			names = append(names, fmt.Sprintf("#%d", t))
+		}
+	}
+
+	return names
+}
+
+// tagIndex returns the registry index of the chunk's i-th tag,
+// archives built before tag names were stored only know the index
+func (c *AssetChunk) tagIndex(i int) uint64 {
+	if len(c.TagKeys) == len(c.Tags) {
+		return findRegisteredTag(c.TagKeys[i])
+	}
+
+	return c.Tags[i]
+}
+
+// findRegisteredTag returns the tag's index in RegisteredTags, unknown tags map to "none"
+func findRegisteredTag(name string) uint64 {
+	for i, v := range RegisteredTags {
+		if v == name {
+			return uint64(i)
+		}
+	}
+
+	return 0
+}
+
+// ArchiveNameFromTagFile returns the archive file name built from the .rtag file
+func ArchiveNameFromTagFile(tagFileName string) string {
+	base := path.Base(filepath.ToSlash(tagFileName))
+	return base[:len(base)-len(path.Ext(base))] + ".dta"
+}
+
+func mapFileTypeStringToID(class string) uint16 {
+	switch class {
+	case "gfx":
+		fallthrough
+	case "sprite":
+		{
+			return fileTypeSprite
+		}
+
+	case "anim":
+		{
+			return fileTypeAseprite
+		}
+
+	case "map":
+		{
+			return fileTypeMap
+		}
+
+	case "music":
+		{
+			return fileTypeMusic
+		}
+
+	default:
+		{
+			return fileTypeGeneric
+		}
+	}
+}
+
+func mapFileTypeIDToString(id uint16) string {
+	switch id {
+	case fileTypeSprite:
+		return "sprite"
+	case fileTypeAseprite:
+		return "anim"
+	case fileTypeMap:
+		return "map"
+	case fileTypeMusic:
+		return "music"
+	default:
+		return "generic"
+	}
+}
+
+func setPropertyIfSet(src *string, value, fallback string) {
+	if value == "" {
+		*src = fallback
+	} else {
+		*src = value
+	}
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checksumChunkData(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
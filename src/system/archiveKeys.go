@@ -0,0 +1,280 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package system
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ArchiveEncryptionKey is the key we use to access our assets.
+	// It can be overridden at build time using:
+	//   -ldflags "-X github.com/zaklaus/rurik/src/system.ArchiveEncryptionKey=secret"
+	// An empty key produces unencrypted archives.
+	ArchiveEncryptionKey = "letmein123"
+
+	// ArchiveTrustedKeys is a comma-separated list of hex-encoded ed25519 public keys
+	// whose signatures are accepted. It can be overridden at build time the same way
+	// as ArchiveEncryptionKey.
+	ArchiveTrustedKeys = ""
+
+	// ArchiveSigningKey is used to sign newly built archives, archives are left unsigned if nil
+	ArchiveSigningKey ed25519.PrivateKey
+
+	// RequireSignedArchives rejects archives not signed by any of the trusted keys,
+	// patches and mods which aren't signed are skipped
+	RequireSignedArchives bool
+
+	trustedKeys []ed25519.PublicKey
+)
+
+const (
+	// EnvArchiveKey overrides the archive encryption key, an empty value disables encryption
+	EnvArchiveKey = "RURIK_ARCHIVE_KEY"
+
+	// EnvArchiveKeyFile points to a file containing the archive encryption key
+	EnvArchiveKeyFile = "RURIK_ARCHIVE_KEY_FILE"
+
+	// EnvArchiveSigningKeyFile points to a file containing the ed25519 private key used for signing
+	EnvArchiveSigningKeyFile = "RURIK_ARCHIVE_SIGNING_KEY_FILE"
+
+	// EnvArchiveTrustedKeys contains a comma-separated list of trusted ed25519 public keys
+	EnvArchiveTrustedKeys = "RURIK_ARCHIVE_TRUSTED_KEYS"
+
+	// EnvRequireSignedArchives makes the game accept only archives signed by a trusted key
+	EnvRequireSignedArchives = "RURIK_REQUIRE_SIGNED"
+)
+
+// InitArchiveKeys loads archive keys from the environment.
+// Run-time values take precedence over the ones provided at build time.
+func InitArchiveKeys() error {
+	if fileName := os.Getenv(EnvArchiveKeyFile); fileName != "" {
+		key, err := ReadKeyFile(fileName)
+
+		if err != nil {
+			return err
+		}
+
+		ArchiveEncryptionKey = key
+	}
+
+	if key, ok := os.LookupEnv(EnvArchiveKey); ok {
+		ArchiveEncryptionKey = key
+	}
+
+	if fileName := os.Getenv(EnvArchiveSigningKeyFile); fileName != "" {
+		key, err := LoadSigningKey(fileName)
+
+		if err != nil {
+			return err
+		}
+
+		ArchiveSigningKey = key
+	}
+
+	if required := os.Getenv(EnvRequireSignedArchives); required != "" {
+		isRequired, err := strconv.ParseBool(required)
+
+		if err != nil {
+			return fmt.Errorf("%s has to be a boolean, got '%s'", EnvRequireSignedArchives, required)
+		}
+
+		RequireSignedArchives = isRequired
+	}
+
+	keys := ArchiveTrustedKeys
+
+	if envKeys := os.Getenv(EnvArchiveTrustedKeys); envKeys != "" {
+		keys = strings.Join([]string{keys, envKeys}, ",")
+	}
+
+	trustedKeys = []ed25519.PublicKey{}
+
+	for _, v := range strings.Split(keys, ",") {
+		v = strings.TrimSpace(v)
+
+		if v == "" {
+			continue
+		}
+
+		err := TrustArchiveKey(v)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TrustArchiveKey adds a hex-encoded ed25519 public key to the list of trusted signers
+func TrustArchiveKey(hexKey string) error {
+	key, err := hex.DecodeString(strings.TrimSpace(hexKey))
+
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid archive public key '%s'", hexKey)
+	}
+
+	trustedKeys = append(trustedKeys, ed25519.PublicKey(key))
+	return nil
+}
+
+// ReadKeyFile reads a key stored inside of a text file
+func ReadKeyFile(fileName string) (string, error) {
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		return "", fmt.Errorf("key file '%s' could not be read: %s", fileName, err.Error())
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// LoadSigningKey reads a hex-encoded ed25519 private key from a file
+func LoadSigningKey(fileName string) (ed25519.PrivateKey, error) {
+	data, err := ReadKeyFile(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := hex.DecodeString(data)
+
+	if err != nil || len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("key file '%s' does not contain a valid ed25519 private key", fileName)
+	}
+
+	return ed25519.PrivateKey(key), nil
+}
+
+// GenerateSigningKey creates a new ed25519 key pair, both keys are hex-encoded
+func GenerateSigningKey() (string, string, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	return hex.EncodeToString(pub), hex.EncodeToString(priv), nil
+}
+
+func isTrustedArchiveKey(key ed25519.PublicKey) bool {
+	for _, v := range trustedKeys {
+		if bytes.Equal(v, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// signArchiveData signs the archive header and table of contents
+func signArchiveData(msg []byte) ([]byte, []byte) {
+	return ArchiveSigningKey.Public().(ed25519.PublicKey), ed25519.Sign(ArchiveSigningKey, msg)
+}
+
+// verifyArchiveSignature checks the signature and the signer's trust
+func verifyArchiveSignature(a *AssetArchive, signer ed25519.PublicKey, msg, signature []byte) error {
+	if signer != nil {
+		if !ed25519.Verify(signer, msg, signature) {
+			return fmt.Errorf("archive signature is invalid")
+		}
+
+		a.signer = signer
+	}
+
+	if RequireSignedArchives && !a.IsTrusted() {
+		return fmt.Errorf("archive is not signed by a trusted key")
+	}
+
+	return nil
+}
+
+// IsEncrypted tells us whether the archive was stored encrypted
+func (a *AssetArchive) IsEncrypted() bool {
+	return a.encrypted
+}
+
+// Signer returns the public key the archive was signed with, nil if unsigned
+func (a *AssetArchive) Signer() ed25519.PublicKey {
+	return a.signer
+}
+
+// IsTrusted tells us whether the archive was signed by one of the trusted keys
+func (a *AssetArchive) IsTrusted() bool {
+	return a.signer != nil && isTrustedArchiveKey(a.signer)
+}
+
+func createHash(key string) string {
+	hasher := md5.New()
+	hasher.Write([]byte(key))
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+func encrypt(data []byte, passphrase string) ([]byte, error) {
+	block, err := aes.NewCipher([]byte(createHash(passphrase)))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := gcm.Seal(nonce, nonce, data, nil)
+	return ciphertext, nil
+}
+
+func decrypt(data []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("archive is encrypted but no key was provided")
+	}
+	key := []byte(createHash(passphrase))
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("archive data is truncated")
+	}
+	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("archive could not be decrypted, wrong key or tampered data")
+	}
+	return plaintext, nil
+}
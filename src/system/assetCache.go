@@ -0,0 +1,210 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package system
+
+import (
+	"container/list"
+	"sync"
+)
+
+var (
+	// AssetCacheBudget is the amount of memory in bytes unreferenced cached assets may occupy
+	AssetCacheBudget = 64 << 20
+
+	// GlobalAssetScope owns assets requested while no map is loaded, it is never released
+	GlobalAssetScope = ""
+
+	assetCache  = newResourceCache()
+	assetScopes []string
+)
+
+// cachedResource is a loaded asset kept in memory.
+// Resources referenced by a scope are never evicted, unreferenced ones are evicted
+// in the least-recently-used order once the cache exceeds its budget.
+type cachedResource struct {
+	key    string
+	size   int
+	value  interface{}
+	scopes map[string]bool
+
+	// unload frees the resource as soon as the last scope releases it
+	unload func()
+	elem   *list.Element
+}
+
+type resourceCache struct {
+	mutex   sync.Mutex
+	entries map[string]*cachedResource
+	lru     *list.List
+	size    int
+}
+
+func newResourceCache() *resourceCache {
+	return &resourceCache{
+		entries: make(map[string]*cachedResource),
+		lru:     list.New(),
+	}
+}
+
+// PushAssetScope makes the assets requested from now on owned by the scope
+func PushAssetScope(scope string) {
+	assetScopes = append(assetScopes, scope)
+}
+
+// PopAssetScope restores the previous asset scope
+func PopAssetScope() {
+	if len(assetScopes) > 0 {
+		assetScopes = assetScopes[:len(assetScopes)-1]
+	}
+}
+
+// CurrentAssetScope returns the scope assets are currently acquired by.
+// It defaults to the name of the current map.
+func CurrentAssetScope() string {
+	if len(assetScopes) > 0 {
+		return assetScopes[len(assetScopes)-1]
+	}
+
+	return MapName
+}
+
+// ReleaseAssetScope drops all references held by the scope, freeing its textures
+func ReleaseAssetScope(scope string) {
+	if scope == GlobalAssetScope {
+		return
+	}
+
+	c := assetCache
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, v := range c.entries {
+		if !v.scopes[scope] {
+			continue
+		}
+
+		delete(v.scopes, scope)
+
+		if len(v.scopes) == 0 && v.unload != nil {
+			c.evict(v)
+		}
+	}
+
+	c.trim(nil)
+}
+
+// AssetCacheStats returns the number of cached assets and their total size in bytes
+func AssetCacheStats() (int, int) {
+	c := assetCache
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.entries), c.size
+}
+
+// FlushAssetCache evicts every unreferenced asset
+func FlushAssetCache() {
+	c := assetCache
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, v := range c.entries {
+		if len(v.scopes) == 0 {
+			c.evict(v)
+		}
+	}
+}
+
+// get retrieves a resource and marks it as recently used
+func (c *resourceCache) get(key string) *cachedResource {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	res, ok := c.entries[key]
+
+	if !ok {
+		return nil
+	}
+
+	c.lru.MoveToFront(res.elem)
+	return res
+}
+
+// put stores a new resource, evicting old ones if over budget.
+// The new resource itself is never evicted, so callers can still acquire it.
+func (c *resourceCache) put(res *cachedResource) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if old, ok := c.entries[res.key]; ok {
+		c.evict(old)
+	}
+
+	if res.scopes == nil {
+		res.scopes = make(map[string]bool)
+	}
+
+	res.elem = c.lru.PushFront(res)
+	c.entries[res.key] = res
+	c.size += res.size
+	c.trim(res)
+}
+
+// take removes the resource from the cache and hands it over to the caller
+func (c *resourceCache) take(key string) *cachedResource {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	res, ok := c.entries[key]
+
+	if !ok {
+		return nil
+	}
+
+	c.evict(res)
+	return res
+}
+
+// acquire adds a reference owned by the current scope
+func (c *resourceCache) acquire(res *cachedResource) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	res.scopes[CurrentAssetScope()] = true
+}
+
+// trim evicts unreferenced resources until the cache fits its budget, keep is left in place
+func (c *resourceCache) trim(keep *cachedResource) {
+	for e := c.lru.Back(); e != nil && c.size > AssetCacheBudget; {
+		res := e.Value.(*cachedResource)
+		e = e.Prev()
+
+		if len(res.scopes) == 0 && res != keep {
+			c.evict(res)
+		}
+	}
+}
+
+func (c *resourceCache) evict(res *cachedResource) {
+	if res.unload != nil {
+		res.unload()
+	}
+
+	c.lru.Remove(res.elem)
+	delete(c.entries, res.key)
+	c.size -= res.size
+}
@@ -0,0 +1,232 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package system
+
+import (
+	"log"
+	"sync/atomic"
+
+	goaseprite "github.com/zaklaus/GoAseprite"
+	rl "github.com/zaklaus/raylib-go/raylib"
+)
+
+var (
+	// PreloadUploadsPerFrame limits the amount of textures uploaded to the GPU each frame
+	PreloadUploadsPerFrame = 4
+
+	activePreloads []*AssetPreload
+)
+
+// AssetPreload tracks assets being loaded in the background.
+// Files are read and decoded on a separate goroutine, the results are cached and textures uploaded on the main thread.
+type AssetPreload struct {
+	Scope string
+
+	total    int32
+	done     int32
+	results  chan preloadedAsset
+	progress func(done, total int)
+	isDone   bool
+}
+
+// preloadedAsset is a result of the background loader, either a decoded texture image
+// or a resource ready to be put into the asset cache
+type preloadedAsset struct {
+	path  string
+	image *rl.Image
+	res   *cachedResource
+}
+
+// PreloadAssets starts loading assets in the background, the assets become owned by the scope.
+// Progress callback is invoked on the main thread.
+func PreloadAssets(scope string, textures, anims, files []string, progress func(done, total int)) *AssetPreload {
+	p := &AssetPreload{
+		Scope:    scope,
+		total:    int32(len(textures) + len(anims) + len(files)),
+		results:  make(chan preloadedAsset, len(textures)+len(anims)+len(files)),
+		progress: progress,
+	}
+
+	go p.load(textures, anims, files)
+
+	activePreloads = append(activePreloads, p)
+	return p
+}
+
+// UpdatePreloads uploads decoded textures of all active preloads, it has to be called on the main thread
+func UpdatePreloads() {
+	active := []*AssetPreload{}
+
+	for _, v := range activePreloads {
+		if !v.Update() {
+			active = append(active, v)
+		}
+	}
+
+	activePreloads = active
+}
+
+// Update caches loaded assets, uploads pending textures and reports the progress, returns true once finished
+func (p *AssetPreload) Update() bool {
+	if p.isDone {
+		return true
+	}
+
+	for uploads := 0; uploads < PreloadUploadsPerFrame; {
+		select {
+		case v := <-p.results:
+			if v.image != nil {
+				uploads++
+			}
+
+			p.upload(v)
+		default:
+			uploads = PreloadUploadsPerFrame
+		}
+	}
+
+	done, total := p.Progress()
+
+	if p.progress != nil {
+		p.progress(done, total)
+	}
+
+	p.isDone = done >= total
+	return p.isDone
+}
+
+// Progress returns the number of loaded assets and the total amount
+func (p *AssetPreload) Progress() (int, int) {
+	return int(atomic.LoadInt32(&p.done)), int(p.total)
+}
+
+// IsDone tells us whether all assets have been loaded
+func (p *AssetPreload) IsDone() bool {
+	return p.isDone
+}
+
+// load reads and decodes the assets, it never touches the asset cache, as it runs off the main thread
+func (p *AssetPreload) load(textures, anims, files []string) {
+	for _, v := range files {
+		p.results <- p.loadFile(v)
+	}
+
+	for _, v := range anims {
+		p.results <- p.loadAnim(v)
+	}
+
+	for _, v := range textures {
+		v = ResolveAssetName(v)
+		a, _ := readAssetUncached(v)
+
+		if a == nil {
+			log.Printf("Preload: texture '%s' could not be found!\n", v)
+			p.results <- preloadedAsset{path: v}
+			continue
+		}
+
+		p.results <- preloadedAsset{
+			path:  v,
+			image: rl.LoadImageFromMemory(string(a.Data)),
+		}
+	}
+}
+
+func (p *AssetPreload) loadFile(fileName string) preloadedAsset {
+	a, key := readAssetUncached(ResolveAssetName(fileName))
+
+	if a == nil {
+		log.Printf("Preload: file '%s' could not be found!\n", fileName)
+		return preloadedAsset{}
+	}
+
+	if key == "" {
+		return preloadedAsset{}
+	}
+
+	return preloadedAsset{
+		res: &cachedResource{
+			key:   key,
+			size:  len(a.Data),
+			value: a.Data,
+		},
+	}
+}
+
+func (p *AssetPreload) loadAnim(animPath string) preloadedAsset {
+	animPath = ResolveAssetName(animPath)
+	a, _ := readAssetUncached(animPath)
+
+	if a == nil {
+		log.Printf("Preload: aseprite file '%s' could not be found!\n", animPath)
+		return preloadedAsset{}
+	}
+
+	dat := goaseprite.Load(string(a.Data))
+
+	if dat == nil {
+		log.Printf("Preload: aseprite file '%s' could not be parsed!\n", animPath)
+		return preloadedAsset{}
+	}
+
+	return preloadedAsset{
+		res: &cachedResource{
+			key:   "anim:" + animPath,
+			size:  len(a.Data),
+			value: *dat,
+		},
+	}
+}
+
+// upload stores the loaded asset in the asset cache, it has to be called on the main thread
+func (p *AssetPreload) upload(v preloadedAsset) {
+	defer atomic.AddInt32(&p.done, 1)
+
+	PushAssetScope(p.Scope)
+	defer PopAssetScope()
+
+	if v.res != nil {
+		res := assetCache.get(v.res.key)
+
+		if res == nil {
+			res = v.res
+			assetCache.put(res)
+		}
+
+		assetCache.acquire(res)
+		return
+	}
+
+	if v.path == "" {
+		return
+	}
+
+	if res := assetCache.get("tex:" + v.path); res != nil {
+		if v.image != nil {
+			rl.UnloadImage(v.image)
+		}
+
+		assetCache.acquire(res)
+		return
+	}
+
+	if v.image == nil {
+		return
+	}
+
+	cacheTexture(v.path, v.image)
+}
@@ -0,0 +1,201 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package system
+
+import (
+	"sort"
+	"sync"
+)
+
+// AssetAxis declares a dimension asset variants differ in, e.g. language, season or texture quality
+type AssetAxis struct {
+	Name    string
+	Values  []string
+	Default string
+
+	// Weight decides which axis wins when variants match on different axes, defaults to 1
+	Weight float64
+}
+
+var (
+	assetAxes    = make(map[string]AssetAxis)
+	assetContext = make(map[string]string)
+
+	vfsVariants  = make(map[string][]string)
+	variantCache = make(map[string]string)
+	variantMutex sync.Mutex
+)
+
+// RegisterAssetAxis declares a variant axis, axes declared by archives don't override existing ones
+func RegisterAssetAxis(axis AssetAxis) {
+	variantMutex.Lock()
+	defer variantMutex.Unlock()
+
+	if axis.Weight == 0 {
+		axis.Weight = 1
+	}
+
+	assetAxes[axis.Name] = axis
+	variantCache = make(map[string]string)
+}
+
+// AssetAxes returns all declared variant axes sorted by name
+func AssetAxes() []AssetAxis {
+	variantMutex.Lock()
+	defer variantMutex.Unlock()
+
+	axes := []AssetAxis{}
+
+	for _, v := range assetAxes {
+		axes = append(axes, v)
+	}
+
+	sort.Slice(axes, func(i, j int) bool {
+		return axes[i].Name < axes[j].Name
+	})
+
+	return axes
+}
+
+// SetAssetContext selects the active value of a variant axis, an empty value restores the default
+func SetAssetContext(axis, value string) {
+	variantMutex.Lock()
+	defer variantMutex.Unlock()
+
+	if value == "" {
+		delete(assetContext, axis)
+	} else {
+		assetContext[axis] = value
+	}
+
+	variantCache = make(map[string]string)
+}
+
+// GetAssetContext returns the active value of a variant axis
+func GetAssetContext(axis string) string {
+	variantMutex.Lock()
+	defer variantMutex.Unlock()
+
+	return getAssetContext(axis)
+}
+
+func getAssetContext(axis string) string {
+	if v, ok := assetContext[axis]; ok {
+		return v
+	}
+
+	return assetAxes[axis].Default
+}
+
+// ResolveAssetName returns the name of the file variant best matching the active context.
+// Variants matching on heavier axes win, ties are broken by layer priority and then by file name.
+func ResolveAssetName(fileName string) string {
+	variantMutex.Lock()
+	defer variantMutex.Unlock()
+
+	if res, ok := variantCache[fileName]; ok {
+		return res
+	}
+
+	res := fileName
+	bestScore := -1.0
+	bestPriority := 0
+
+	if _, ok := vfsIndex[fileName]; ok {
+		bestScore = 0
+		bestPriority = vfsIndex[fileName].layer.priority
+	}
+
+	// NOTE: candidates are sorted by name, so the first one wins on a tie
+	for _, v := range vfsVariants[fileName] {
+		e, ok := vfsIndex[v]
+
+		if !ok {
+			continue
+		}
+
+		score, ok := variantScore(e.chunk.Axes)
+
+		if !ok {
+			continue
+		}
+
+		if score > bestScore || (score == bestScore && e.layer.priority > bestPriority) {
+			res = v
+			bestScore = score
+			bestPriority = e.layer.priority
+		}
+	}
+
+	variantCache[fileName] = res
+	return res
+}
+
+// AssetVariants returns all variants declared for the file
+func AssetVariants(fileName string) []string {
+	variantMutex.Lock()
+	defer variantMutex.Unlock()
+
+	return vfsVariants[fileName]
+}
+
+func variantScore(axes map[string]string) (float64, bool) {
+	var score float64
+
+	for k, v := range axes {
+		if getAssetContext(k) != v {
+			return 0, false
+		}
+
+		score += assetAxes[k].Weight
+	}
+
+	return score, true
+}
+
+// rebuildVariants indexes variants of all mounted files
+func rebuildVariants() {
+	variantMutex.Lock()
+	defer variantMutex.Unlock()
+
+	vfsVariants = make(map[string][]string)
+	variantCache = make(map[string]string)
+
+	for _, l := range vfsLayers {
+		if l.archive == nil {
+			continue
+		}
+
+		for _, ax := range l.archive.Axes {
+			if _, ok := assetAxes[ax.Name]; !ok {
+				if ax.Weight == 0 {
+					ax.Weight = 1
+				}
+
+				assetAxes[ax.Name] = ax
+			}
+		}
+	}
+
+	for _, name := range vfsNames {
+		variant := vfsIndex[name].chunk.Variant
+
+		if variant != "" && variant != name {
+			vfsVariants[variant] = append(vfsVariants[variant], name)
+		}
+	}
+}
@@ -0,0 +1,543 @@
+/*
+   Copyright 2019 Dominik Madarász <zaklaus@madaraszd.net>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package system
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Layer priorities, files in layers with higher priority override the lower ones
+const (
+	LayerPriorityBase  = 0
+	LayerPriorityPatch = 100
+	LayerPriorityMod   = 200
+	LayerPriorityLoose = 300
+)
+
+var (
+	// PatchesDir contains archives overriding the base game data
+	PatchesDir = "data/patches"
+
+	// ModsDir contains mod directories, each holding loose files and archives
+	ModsDir = "mods"
+
+	// LooseFilesDir contains loose files overriding everything else
+	LooseFilesDir = "assets"
+
+	vfsLayers []*vfsLayer
+	vfsIndex  = make(map[string]*vfsEntry)
+	vfsNames  []string
+)
+
+type vfsLayer struct {
+	name     string
+	priority int
+	archive  *AssetArchive
+	dir      string
+	files    []string
+}
+
+type vfsEntry struct {
+	layer *vfsLayer
+	chunk *AssetChunk
+	index int
+}
+
+// MountArchive adds an archive layer to the virtual filesystem
+func MountArchive(name string, a *AssetArchive, priority int) {
+	files := []string{}
+
+	for _, v := range a.Chunks {
+		files = append(files, v.FileName)
+	}
+
+	mountLayer(&vfsLayer{
+		name:     name,
+		priority: priority,
+		archive:  a,
+		files:    files,
+	})
+}
+
+// MountDirectory adds a directory of loose files to the virtual filesystem
+func MountDirectory(dir string, priority int) error {
+	files := []string{}
+
+	err := filepath.Walk(dir, func(fileName string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// NOTE: archives next to loose files are mounted as layers of their own
+		if info.IsDir() || filepath.Ext(fileName) == ".dta" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, fileName)
+
+		if err != nil {
+			return err
+		}
+
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("directory '%s' could not be mounted: %s", dir, err.Error())
+	}
+
+	mountLayer(&vfsLayer{
+		name:     dir,
+		priority: priority,
+		dir:      dir,
+		files:    files,
+	})
+
+	return nil
+}
+
+// UnmountLayer removes a previously mounted layer
+func UnmountLayer(name string) {
+	for i, v := range vfsLayers {
+		if v.name == name {
+			if v.archive != nil {
+				v.archive.Close()
+			}
+
+			vfsLayers = append(vfsLayers[:i], vfsLayers[i+1:]...)
+			rebuildIndex()
+			return
+		}
+	}
+}
+
+// MountedLayers returns the names of all mounted layers, highest priority first
+func MountedLayers() []string {
+	names := []string{}
+
+	for i := len(vfsLayers) - 1; i >= 0; i-- {
+		names = append(names, vfsLayers[i].name)
+	}
+
+	return names
+}
+
+// ResolveAssetLayer returns the name of the layer a file is served from
+func ResolveAssetLayer(fileName string) (string, bool) {
+	e, ok := vfsIndex[fileName]
+
+	if !ok {
+		return "", false
+	}
+
+	return e.layer.name, true
+}
+
+// AssetFileNames returns a sorted list of every file available in the virtual filesystem
+func AssetFileNames() []string {
+	return vfsNames
+}
+
+func mountLayer(l *vfsLayer) {
+	UnmountLayer(l.name)
+
+	vfsLayers = append(vfsLayers, l)
+	sort.SliceStable(vfsLayers, func(i, j int) bool {
+		return vfsLayers[i].priority < vfsLayers[j].priority
+	})
+
+	rebuildIndex()
+}
+
+func rebuildIndex() {
+	vfsIndex = make(map[string]*vfsEntry)
+
+	for _, l := range vfsLayers {
+		for i, v := range l.files {
+			e := &vfsEntry{layer: l, index: i}
+
+			if l.archive != nil {
+				e.chunk = &l.archive.Chunks[i]
+			} else {
+				e.chunk = newLooseChunk(l, v, vfsIndex[v])
+			}
+
+			vfsIndex[v] = e
+		}
+	}
+
+	vfsNames = make([]string, 0, len(vfsIndex))
+
+	for k := range vfsIndex {
+		vfsNames = append(vfsNames, k)
+	}
+
+	sort.Strings(vfsNames)
+	rebuildVariants()
+	rebuildAssetDatabase()
+}
+
+// rebuildAssetDatabase lists the archives of the mounted layers, lowest priority first
+func rebuildAssetDatabase() {
+	AssetDatabase = []AssetArchive{}
+
+	for _, l := range vfsLayers {
+		if l.archive != nil {
+			AssetDatabase = append(AssetDatabase, *l.archive)
+		}
+	}
+}
+
+// newLooseChunk describes a loose file, overrides keep the metadata of the chunk they replace,
+// so its type, tags and variant info don't depend on where the file came from
+func newLooseChunk(l *vfsLayer, fileName string, prev *vfsEntry) *AssetChunk {
+	if prev == nil {
+		return &AssetChunk{
+			FileName: fileName,
+			Name:     l.name,
+			Type:     mapFileTypeStringToID(strings.SplitN(fileName, "/", 2)[0]),
+		}
+	}
+
+	return &AssetChunk{
+		FileName:    fileName,
+		Name:        l.name,
+		Author:      prev.chunk.Author,
+		Description: prev.chunk.Description,
+		Type:        prev.chunk.Type,
+		Tags:        prev.chunk.Tags,
+		TagValues:   prev.chunk.TagValues,
+		TagKeys:     prev.chunk.TagKeys,
+		ExtraData:   prev.chunk.ExtraData,
+		Variant:     prev.chunk.Variant,
+		Axes:        prev.chunk.Axes,
+	}
+}
+
+// mountOverrides mounts patch archives, mods and loose files on top of the base archives
+func mountOverrides() {
+	patches, _ := filepath.Glob(filepath.Join(PatchesDir, "*.dta"))
+	sort.Strings(patches)
+
+	for _, v := range patches {
+		if err := mountArchiveFile(v, LayerPriorityPatch); err != nil {
+			log.Printf("Patch '%s' has been skipped: %s\n", v, err.Error())
+		}
+	}
+
+	mods, _ := ioutil.ReadDir(ModsDir)
+
+	for i, v := range mods {
+		if !v.IsDir() {
+			continue
+		}
+
+		modDir := filepath.Join(ModsDir, v.Name())
+		archives, _ := filepath.Glob(filepath.Join(modDir, "*.dta"))
+		sort.Strings(archives)
+
+		for _, a := range archives {
+			if err := mountArchiveFile(a, LayerPriorityMod+i); err != nil {
+				log.Printf("Mod archive '%s' has been skipped: %s\n", a, err.Error())
+			}
+		}
+
+		// NOTE: loose files can't be signed, they would bypass the signature checks
+		if RequireSignedArchives {
+			log.Printf("Loose files of mod '%s' have been skipped, only signed archives are allowed\n", v.Name())
+		} else if err := MountDirectory(modDir, LayerPriorityMod+i); err != nil {
+			log.Fatalf("Mod '%s' could not be loaded: %s\n", v.Name(), err.Error())
+		}
+
+		log.Printf("Mod '%s' has been mounted!\n", v.Name())
+	}
+
+	if _, err := os.Stat(LooseFilesDir); err == nil {
+		if err := MountDirectory(LooseFilesDir, LayerPriorityLoose); err != nil {
+			log.Fatalf("Loose files could not be loaded: %s\n", err.Error())
+		}
+	}
+}
+
+// mountArchiveFile opens the archive and mounts it, archives not signed by a trusted key are refused if signatures are required
+func mountArchiveFile(fileName string, priority int) error {
+	db, err := OpenArchiveFile(fileName)
+
+	if err != nil {
+		return err
+	}
+
+	MountArchive(fileName, db, priority)
+	return nil
+}
+
+// readAsset returns the resolved chunk with its data loaded
+func readAsset(fileName string) *AssetChunk {
+	e, ok := vfsIndex[fileName]
+
+	if !ok {
+		return nil
+	}
+
+	if e.layer.archive != nil {
+		return readArchiveAsset(e)
+	}
+
+	// NOTE: preloaded loose files are handed over once, later reads go to the disk to pick up edits
+	if res := assetCache.take(assetDataKey(e)); res != nil {
+		ch := *e.chunk
+		ch.Data = res.value.([]byte)
+		return &ch
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(e.layer.dir, filepath.FromSlash(fileName)))
+
+	if err != nil {
+		log.Printf("Loose file '%s' could not be read: %s\n", fileName, err.Error())
+		return nil
+	}
+
+	ch := *e.chunk
+	ch.Data = data
+	return &ch
+}
+
+// readArchiveAsset reads the chunk from its archive, keeping the data in the asset cache
+func readArchiveAsset(e *vfsEntry) *AssetChunk {
+	if e.chunk.Data != nil {
+		return e.chunk
+	}
+
+	key := assetDataKey(e)
+	ch := *e.chunk
+
+	if res := assetCache.get(key); res != nil {
+		ch.Data = res.value.([]byte)
+		return &ch
+	}
+
+	data, err := e.layer.archive.ReadChunk(e.index)
+
+	if err != nil {
+		log.Fatalf("Could not load game data: %s!\n", err.Error())
+		return nil
+	}
+
+	assetCache.put(&cachedResource{
+		key:   key,
+		size:  len(data),
+		value: data,
+	})
+
+	ch.Data = data
+	return &ch
+}
+
+// readAssetUncached reads the asset without touching the asset cache, so it can be used off the main thread.
+// It also returns the key the data belongs under in the cache, data kept in memory already returns an empty one.
+func readAssetUncached(fileName string) (*AssetChunk, string) {
+	e, ok := vfsIndex[fileName]
+
+	if !ok {
+		return nil, ""
+	}
+
+	if e.chunk.Data != nil {
+		return e.chunk, ""
+	}
+
+	var data []byte
+	var err error
+
+	if e.layer.archive != nil {
+		data, err = e.layer.archive.ReadChunk(e.index)
+	} else {
+		data, err = ioutil.ReadFile(filepath.Join(e.layer.dir, filepath.FromSlash(fileName)))
+	}
+
+	if err != nil {
+		log.Printf("Could not load game data: %s!\n", err.Error())
+		return nil, ""
+	}
+
+	ch := *e.chunk
+	ch.Data = data
+	return &ch, assetDataKey(e)
+}
+
+func assetDataKey(e *vfsEntry) string {
+	return "data:" + e.layer.name + ":" + e.chunk.FileName
+}
+
+// AssetFS is an io/fs.FS view of the virtual filesystem
+type AssetFS struct{}
+
+// Open opens a file or a directory inside of the virtual filesystem
+func (AssetFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if ch := readAsset(name); ch != nil {
+		return &assetFile{
+			info:   assetFileInfo{name: path.Base(name), size: int64(len(ch.Data))},
+			Reader: bytes.NewReader(ch.Data),
+		}, nil
+	}
+
+	entries, err := readAssetDir(name)
+
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return &assetDir{info: assetFileInfo{name: path.Base(name), isDir: true}, entries: entries}, nil
+}
+
+// ReadFile reads a whole file from the virtual filesystem
+func (AssetFS) ReadFile(name string) ([]byte, error) {
+	ch := readAsset(name)
+
+	if ch == nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return ch.Data, nil
+}
+
+// ReadDir lists a directory inside of the virtual filesystem
+func (AssetFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := readAssetDir(name)
+
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	return entries, nil
+}
+
+func readAssetDir(name string) ([]fs.DirEntry, error) {
+	prefix := ""
+
+	if name != "." {
+		prefix = name + "/"
+	}
+
+	entries := []fs.DirEntry{}
+	seen := make(map[string]bool)
+
+	for _, v := range vfsNames {
+		if !strings.HasPrefix(v, prefix) {
+			continue
+		}
+
+		rest := v[len(prefix):]
+		parts := strings.SplitN(rest, "/", 2)
+
+		if seen[parts[0]] {
+			continue
+		}
+
+		seen[parts[0]] = true
+		info := assetFileInfo{name: parts[0], isDir: len(parts) > 1}
+
+		if !info.isDir {
+			info.size = int64(len(vfsIndex[v].chunk.Data))
+		}
+
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+
+	if len(entries) == 0 && name != "." {
+		return nil, fs.ErrNotExist
+	}
+
+	return entries, nil
+}
+
+type assetFileInfo struct {
+	name  string
+	size  int64
+	isDir bool
+}
+
+func (i assetFileInfo) Name() string       { return i.name }
+func (i assetFileInfo) Size() int64        { return i.size }
+func (i assetFileInfo) ModTime() time.Time { return time.Time{} }
+func (i assetFileInfo) IsDir() bool        { return i.isDir }
+func (i assetFileInfo) Sys() interface{}   { return nil }
+
+func (i assetFileInfo) Mode() fs.FileMode {
+	if i.isDir {
+		return fs.ModeDir | 0555
+	}
+
+	return 0444
+}
+
+type assetFile struct {
+	*bytes.Reader
+	info assetFileInfo
+}
+
+func (f *assetFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *assetFile) Close() error               { return nil }
+
+type assetDir struct {
+	info    assetFileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *assetDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *assetDir) Close() error               { return nil }
+
+func (d *assetDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *assetDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(rest) {
+		n = len(rest)
+	}
+
+	d.offset += n
+	return rest[:n], nil
+}